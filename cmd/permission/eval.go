@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/datastore"
+	"github.com/OpenSlides/openslides-permission-service/pkg/permission"
+)
+
+const evalUsage = `Usage: permission eval -dump FILE -user ID [-action NAME -payload JSON] [-fqfields LIST] [-fqids LIST]
+
+Evaluates the permissions of a user against a datastore dump in yaml or json
+format and prints the result with the reasons.
+
+Examples:
+  permission eval -dump db.yml -user 5 -action motion.update -payload '{"id":1}'
+  permission eval -dump db.yml -user 5 -fqfields motion/1/title,motion/1/text
+  permission eval -dump db.yml -user 5 -fqids motion/1
+
+Flags:
+`
+
+// evalCmd implements the subcommand `permission eval`.
+func evalCmd(args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), evalUsage)
+		flags.PrintDefaults()
+	}
+
+	dumpFile := flags.String("dump", "", "file with the datastore dump")
+	userID := flags.Int("user", 0, "id of the user, 0 is the anonymous user")
+	action := flags.String("action", "", "name of the action")
+	payload := flags.String("payload", "{}", "payload of the action as json object or list of objects")
+	fqfields := flags.String("fqfields", "", "comma separated list of fqfields")
+	fqids := flags.String("fqids", "", "comma separated list of fqids")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *dumpFile == "" {
+		return fmt.Errorf("flag -dump is required")
+	}
+
+	if *action == "" && *fqfields == "" && *fqids == "" {
+		return fmt.Errorf("one of the flags -action, -fqfields or -fqids is required")
+	}
+
+	dump, err := loadDump(*dumpFile)
+	if err != nil {
+		return fmt.Errorf("loading dump: %w", err)
+	}
+
+	options, err := permissionOptions(defaultEnv())
+	if err != nil {
+		return fmt.Errorf("reading permission config: %w", err)
+	}
+
+	ps := permission.New(dump, options...)
+	ctx := context.Background()
+
+	if *action != "" {
+		payloads, err := decodePayload(*payload)
+		if err != nil {
+			return fmt.Errorf("decoding payload: %w", err)
+		}
+
+		if err := evalAction(ctx, w, ps, *userID, *action, payloads); err != nil {
+			return fmt.Errorf("evaluating action: %w", err)
+		}
+	}
+
+	if *fqfields != "" {
+		if err := evalFQFields(ctx, w, ps, *userID, strings.Split(*fqfields, ",")); err != nil {
+			return fmt.Errorf("evaluating fqfields: %w", err)
+		}
+	}
+
+	if *fqids != "" {
+		if err := evalFQIDs(ctx, w, ps, *userID, strings.Split(*fqids, ",")); err != nil {
+			return fmt.Errorf("evaluating fqids: %w", err)
+		}
+	}
+	return nil
+}
+
+func loadDump(path string) (datastore.Dump, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open dump file: %w", err)
+	}
+	defer f.Close()
+
+	dump, err := datastore.LoadDump(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return dump, nil
+}
+
+// decodePayload decodes a json object or a list of json objects.
+func decodePayload(payload string) ([]map[string]json.RawMessage, error) {
+	payload = strings.TrimSpace(payload)
+	if strings.HasPrefix(payload, "[") {
+		var payloads []map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(payload), &payloads); err != nil {
+			return nil, err
+		}
+		return payloads, nil
+	}
+
+	var p map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return nil, err
+	}
+	return []map[string]json.RawMessage{p}, nil
+}
+
+func evalAction(ctx context.Context, w io.Writer, ps *permission.Permission, userID int, action string, payloads []map[string]json.RawMessage) error {
+	allowed, reason, err := ps.IsAllowedWithReason(ctx, action, userID, payloads)
+	if err != nil {
+		return err
+	}
+
+	if allowed {
+		fmt.Fprintf(w, "%s: allowed\n", action)
+		return nil
+	}
+	fmt.Fprintf(w, "%s: not allowed: %s\n", action, reason)
+	return nil
+}
+
+func evalFQFields(ctx context.Context, w io.Writer, ps *permission.Permission, userID int, fqfields []string) error {
+	allowed, reasons, err := ps.RestrictFQFieldsExplained(ctx, userID, fqfields)
+	if err != nil {
+		return err
+	}
+
+	for _, fqfield := range fqfields {
+		if allowed[fqfield] {
+			fmt.Fprintf(w, "%s: visible\n", fqfield)
+			continue
+		}
+		fmt.Fprintf(w, "%s: not visible: %s\n", fqfield, reasons[fqfield])
+	}
+	return nil
+}
+
+func evalFQIDs(ctx context.Context, w io.Writer, ps *permission.Permission, userID int, fqids []string) error {
+	visible, err := ps.RestrictFQIDs(ctx, userID, fqids)
+	if err != nil {
+		return err
+	}
+
+	for _, fqid := range fqids {
+		fields := visible[fqid]
+		if len(fields) > 0 {
+			sort.Strings(fields)
+			fmt.Fprintf(w, "%s: %s\n", fqid, strings.Join(fields, ", "))
+			continue
+		}
+
+		// Explain, why the object can not be seen.
+		_, reasons, err := ps.RestrictFQFieldsExplained(ctx, userID, []string{fqid + "/id"})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s: not visible: %s\n", fqid, reasons[fqid+"/id"])
+	}
+	return nil
+}
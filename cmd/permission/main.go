@@ -3,20 +3,49 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/OpenSlides/openslides-permission-service/internal/datastore"
 	permHTTP "github.com/OpenSlides/openslides-permission-service/internal/http"
+	"github.com/OpenSlides/openslides-permission-service/internal/metrics"
 	"github.com/OpenSlides/openslides-permission-service/pkg/permission"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check-coverage":
+			complete, err := checkCoverage()
+			if err != nil {
+				log.Fatalf("Fatal error: %v", err)
+			}
+			if !complete {
+				os.Exit(1)
+			}
+			return
+
+		case "eval":
+			if err := evalCmd(os.Args[2:], os.Stdout); err != nil {
+				if errors.Is(err, flag.ErrHelp) {
+					return
+				}
+				log.Fatalf("Error: %v", err)
+			}
+			return
+		}
+	}
+
 	if err := run(); err != nil {
 		log.Fatalf("Fatal error: %v", err)
 	}
@@ -27,10 +56,28 @@ func defaultEnv() map[string]string {
 		"PERMISSION_HOST": "",
 		"PERMISSION_PORT": "9005",
 
-		"DATASTORE":                 "fake",
-		"DATASTORE_READER_HOST":     "localhost",
-		"DATASTORE_READER_PORT":     "9010",
-		"DATASTORE_READER_PROTOCOL": "http",
+		"DATASTORE":                  "fake",
+		"DATASTORE_READER_HOST":      "localhost",
+		"DATASTORE_READER_PORT":      "9010",
+		"DATASTORE_READER_PROTOCOL":  "http",
+		"DATASTORE_TIMEOUT":          "3s",
+		"DATASTORE_MAX_RETRIES":      "2",
+		"DATASTORE_RETRY_BACKOFF":    "100ms",
+		"DATASTORE_MAX_IDLE_CONNS":   "100",
+		"DATASTORE_BREAKER_FAILURES": "5",
+		"DATASTORE_BREAKER_COOLDOWN": "10s",
+		"DATASTORE_CACHE_SIZE":       "0",
+		"DATASTORE_CACHE_TTL":        "1m",
+
+		"PERMISSION_RULES_FILE":      "",
+		"PERMISSION_PARALLELISM":     "4",
+		"PERMISSION_STRICT_COVERAGE": "false",
+		"PERMISSION_LOG_LEVEL":       "info",
+
+		"PERMISSION_AUDIT_FILE":            "",
+		"PERMISSION_AUDIT_SUPERADMIN_FILE": "",
+		"PERMISSION_AUDIT_MAX_SIZE":        "104857600",
+		"PERMISSION_AUDIT_MAX_FILES":       "10",
 	}
 
 	for k := range defaults {
@@ -53,18 +100,89 @@ func run() error {
 		fmt.Println("Use fake datastore")
 	case "service":
 		addr := fmt.Sprintf("%s://%s:%s", env["DATASTORE_READER_PROTOCOL"], env["DATASTORE_READER_HOST"], env["DATASTORE_READER_PORT"])
-		edp = &datastore.Datastore{Addr: addr}
+		options, err := datastoreOptions(env)
+		if err != nil {
+			return fmt.Errorf("reading datastore config: %w", err)
+		}
+		edp = datastore.New(addr, options...)
 		fmt.Printf("Use datastore reader on %s\n", addr)
 	default:
 		return fmt.Errorf("Unknown datastore type %s", env["DATASTORE"])
 	}
 
-	ps := permission.New(edp)
+	// Put a cache in front of the ExternalDataProvider.
+	cacheSize, err := strconv.Atoi(env["DATASTORE_CACHE_SIZE"])
+	if err != nil {
+		return fmt.Errorf("invalid value for DATASTORE_CACHE_SIZE: %w", err)
+	}
+
+	var cache *datastore.Cache
+	if cacheSize > 0 {
+		ttl, err := time.ParseDuration(env["DATASTORE_CACHE_TTL"])
+		if err != nil {
+			return fmt.Errorf("invalid value for DATASTORE_CACHE_TTL: %w", err)
+		}
+
+		cache = datastore.NewCache(edp, cacheSize, ttl)
+		edp = cache
+		fmt.Printf("Use datastore cache with %d keys\n", cacheSize)
+	}
+
+	options, err := permissionOptions(env)
+	if err != nil {
+		return fmt.Errorf("reading permission config: %w", err)
+	}
+
+	logLevel, err := permission.ParseLevel(env["PERMISSION_LOG_LEVEL"])
+	if err != nil {
+		return fmt.Errorf("invalid value for PERMISSION_LOG_LEVEL: %w", err)
+	}
+	logger := permission.NewJSONLogger(os.Stderr, logLevel)
+	options = append(options, permission.WithLogger(logger))
+
+	audit, err := auditFile(env)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	if audit != nil {
+		defer func() {
+			if err := audit.Close(); err != nil {
+				log.Printf("Error on closing audit log: %v", err)
+			}
+		}()
+		options = append(options, permission.WithAudit(audit))
+	}
+
+	ps := permission.New(edp, options...)
+
+	// Check, that all actions and collections are handled.
+	strict, err := strconv.ParseBool(env["PERMISSION_STRICT_COVERAGE"])
+	if err != nil {
+		return fmt.Errorf("invalid value for PERMISSION_STRICT_COVERAGE: %w", err)
+	}
+
+	coverage := ps.Coverage()
+	if err := coverage.WriteReport(os.Stdout); err != nil {
+		return fmt.Errorf("writing coverage report: %w", err)
+	}
+	if strict && !coverage.Complete() {
+		return fmt.Errorf("not all actions and collections are handled")
+	}
 
 	// Register handlers.
 	mux := http.NewServeMux()
 	permHTTP.Health(mux, ps)
+	permHTTP.Metrics(mux, metrics.DefaultRegistry)
+	permHTTP.LogLevel(mux, logger)
 	permHTTP.IsAllowed(mux, ps)
+	permHTTP.IsAllowedPerPayload(mux, ps)
+	permHTTP.RestrictFQFields(mux, ps)
+	permHTTP.RestrictFQIDs(mux, ps)
+	permHTTP.RestrictValues(mux, ps)
+	permHTTP.EffectivePermissions(mux, ps)
+	if cache != nil {
+		permHTTP.Invalidate(mux, cache)
+	}
 
 	// Create http server.
 	listenAddr := ":" + env["PERMISSION_PORT"]
@@ -93,6 +211,121 @@ func run() error {
 	return nil
 }
 
+// permissionOptions returns the options for the permission service from the
+// environment.
+func permissionOptions(env map[string]string) ([]permission.Option, error) {
+	parallelism, err := strconv.Atoi(env["PERMISSION_PARALLELISM"])
+	if err != nil || parallelism < 1 {
+		return nil, fmt.Errorf("invalid value for PERMISSION_PARALLELISM: `%s`", env["PERMISSION_PARALLELISM"])
+	}
+
+	options := []permission.Option{permission.WithParallelism(parallelism)}
+	if path := env["PERMISSION_RULES_FILE"]; path != "" {
+		rules, err := loadRules(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading rules: %w", err)
+		}
+		options = append(options, permission.WithRules(rules))
+		fmt.Printf("Use rules from %s\n", path)
+	}
+	return options, nil
+}
+
+// auditFile opens the audit log from the environment. It returns nil, if
+// PERMISSION_AUDIT_FILE is not set.
+//
+// If PERMISSION_AUDIT_SUPERADMIN_FILE is not set, the decisions for
+// superadmins are written next to the audit file with the suffix _superadmin.
+func auditFile(env map[string]string) (*permission.AuditFile, error) {
+	path := env["PERMISSION_AUDIT_FILE"]
+	if path == "" {
+		return nil, nil
+	}
+
+	superadminPath := env["PERMISSION_AUDIT_SUPERADMIN_FILE"]
+	if superadminPath == "" {
+		ext := filepath.Ext(path)
+		superadminPath = strings.TrimSuffix(path, ext) + "_superadmin" + ext
+	}
+
+	maxSize, err := strconv.ParseInt(env["PERMISSION_AUDIT_MAX_SIZE"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for PERMISSION_AUDIT_MAX_SIZE: %w", err)
+	}
+
+	maxFiles, err := strconv.Atoi(env["PERMISSION_AUDIT_MAX_FILES"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for PERMISSION_AUDIT_MAX_FILES: %w", err)
+	}
+
+	audit, err := permission.NewAuditFile(path, superadminPath, permission.WithAuditRotation(maxSize, maxFiles))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Write audit log to %s and %s\n", path, superadminPath)
+	return audit, nil
+}
+
+// checkCoverage prints all actions and collections without a handler.
+//
+// It returns false, if something is missing.
+func checkCoverage() (bool, error) {
+	options, err := permissionOptions(defaultEnv())
+	if err != nil {
+		return false, fmt.Errorf("reading permission config: %w", err)
+	}
+
+	coverage := permission.New(nil, options...).Coverage()
+	if err := coverage.WriteReport(os.Stdout); err != nil {
+		return false, fmt.Errorf("writing coverage report: %w", err)
+	}
+	return coverage.Complete(), nil
+}
+
+// loadRules reads the rules for simple actions and collections from a file.
+func loadRules(path string) (*permission.Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open rules file: %w", err)
+	}
+	defer f.Close()
+
+	rules, err := permission.LoadRules(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// datastoreOptions returns the options for the datastore client from the
+// environment.
+func datastoreOptions(env map[string]string) ([]datastore.Option, error) {
+	durations := make(map[string]time.Duration)
+	for _, k := range []string{"DATASTORE_TIMEOUT", "DATASTORE_RETRY_BACKOFF", "DATASTORE_BREAKER_COOLDOWN"} {
+		d, err := time.ParseDuration(env[k])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", k, err)
+		}
+		durations[k] = d
+	}
+
+	ints := make(map[string]int)
+	for _, k := range []string{"DATASTORE_MAX_RETRIES", "DATASTORE_MAX_IDLE_CONNS", "DATASTORE_BREAKER_FAILURES"} {
+		i, err := strconv.Atoi(env[k])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", k, err)
+		}
+		ints[k] = i
+	}
+
+	return []datastore.Option{
+		datastore.WithTimeout(durations["DATASTORE_TIMEOUT"]),
+		datastore.WithRetries(ints["DATASTORE_MAX_RETRIES"], durations["DATASTORE_RETRY_BACKOFF"]),
+		datastore.WithMaxIdleConns(ints["DATASTORE_MAX_IDLE_CONNS"]),
+		datastore.WithCircuitBreaker(ints["DATASTORE_BREAKER_FAILURES"], durations["DATASTORE_BREAKER_COOLDOWN"]),
+	}, nil
+}
+
 // waitForShutdown blocks until the service exists.
 //
 // It listens on SIGINT and SIGTERM. If the signal is received for a second
@@ -2,6 +2,7 @@ package collection
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
@@ -13,6 +14,9 @@ func AgendaItem(dp dataprovider.DataProvider) perm.ConnecterFunc {
 	a := &agendaItem{dp}
 	return func(s perm.HandlerStore) {
 		s.RegisterRestricter("agenda_item", perm.CollectionFunc(a.read))
+		s.RegisterValueFilter("agenda_item", "child_ids", a.filterChildIDs)
+
+		registerMeetingDependencies(s, dp, "agenda_item", "is_hidden", "is_internal", "parent_id")
 	}
 }
 
@@ -20,39 +24,50 @@ type agendaItem struct {
 	dp dataprovider.DataProvider
 }
 
+// read restricts agenda items.
+//
+// The children of a hidden or internal item are handled like the parent. So
+// the strictest visibility along the path to the root item is used.
+//
+// The value of the field child_ids is filtered by filterChildIDs.
 func (a *agendaItem) read(ctx context.Context, userID int, fqfields []perm.FQField, result map[string]bool) error {
+	if err := prefetch(ctx, a.dp, fqfields, "meeting_id"); err != nil {
+		return fmt.Errorf("prefetching agenda items: %w", err)
+	}
+
+	if err := a.prefetchAncestors(ctx, uniqueIDs(fqfields)); err != nil {
+		return fmt.Errorf("prefetching ancestors: %w", err)
+	}
+
+	if err := prefetchPerms(ctx, a.dp, userID, fqfields); err != nil {
+		return fmt.Errorf("prefetching permissions: %w", err)
+	}
+
 	grouped, err := groupByMeeting(ctx, a.dp, userID, fqfields)
 	if err != nil {
 		return fmt.Errorf("grouping fqfields: %w", err)
 	}
 
+	visibilities := make(map[int]agendaVisibility)
 	var lastID int
 	var hasPerm bool
 	for _, g := range grouped {
+		ctx := perm.WithLogMeeting(ctx, g.meetingID)
 		for _, fqfield := range g.fqfields {
 			if lastID != fqfield.ID {
 				lastID = fqfield.ID
 				fqid := fmt.Sprintf("agenda_item/%d", fqfield.ID)
-				var isInternal bool
-				if err := a.dp.GetIfExist(ctx, fqid+"/is_internal", &isInternal); err != nil {
-					return fmt.Errorf("getting is_internal field: %w", err)
-				}
-
-				var isHidden bool
-				if err := a.dp.GetIfExist(ctx, fqid+"/is_hidden", &isHidden); err != nil {
-					return fmt.Errorf("getting is_hidden field: %w", err)
+				visibility, err := a.visibility(ctx, fqfield.ID, visibilities)
+				if err != nil {
+					return fmt.Errorf("getting visibility of %s: %w", fqid, err)
 				}
 
-				requiredPerm := perm.AgendaItemCanSee
-				if isInternal {
-					requiredPerm = perm.AgendaItemCanSeeInternal
-				}
-				if isHidden {
-					requiredPerm = perm.AgendaItemCanManage
-				}
+				requiredPerm := visibility.requiredPerm()
 				hasPerm = false
 				if g.perm.Has(requiredPerm) {
 					hasPerm = true
+				} else {
+					perm.LogNotAllowedf(ctx, "User %d does not have the permission %s to see %s", userID, requiredPerm, fqid)
 				}
 			}
 
@@ -75,6 +90,180 @@ func (a *agendaItem) read(ctx context.Context, userID int, fqfields []perm.FQFie
 	return nil
 }
 
+// agendaVisibility is the visibility of an agenda item. A higher value is
+// stricter.
+type agendaVisibility int
+
+const (
+	agendaCommon agendaVisibility = iota
+	agendaInternal
+	agendaHidden
+)
+
+// requiredPerm returns the permission, that is needed to see an agenda item
+// with this visibility.
+func (v agendaVisibility) requiredPerm() perm.TPermission {
+	switch v {
+	case agendaHidden:
+		return perm.AgendaItemCanManage
+	case agendaInternal:
+		return perm.AgendaItemCanSeeInternal
+	default:
+		return perm.AgendaItemCanSee
+	}
+}
+
+// visibility returns the strictest visibility of the agenda item and all its
+// ancestors.
+//
+// The results for the item and all ancestors are saved in the cache, so each
+// item is only looked at once. If the parent_id fields contain a cycle, the
+// walk stops at the first item, that was already visited, and all items of the
+// cycle get the strictest visibility of the cycle.
+func (a *agendaItem) visibility(ctx context.Context, id int, cache map[int]agendaVisibility) (agendaVisibility, error) {
+	var path []int
+	var own []agendaVisibility
+	visited := make(map[int]int)
+
+	var inherited agendaVisibility
+	for current := id; current != 0; {
+		if v, ok := cache[current]; ok {
+			inherited = v
+			break
+		}
+
+		if idx, ok := visited[current]; ok {
+			// All items of a cycle get the strictest visibility of the cycle.
+			for _, v := range own[idx:] {
+				if v > inherited {
+					inherited = v
+				}
+			}
+			break
+		}
+		visited[current] = len(path)
+
+		fqid := fmt.Sprintf("agenda_item/%d", current)
+		var isInternal bool
+		if err := a.dp.GetIfExist(ctx, fqid+"/is_internal", &isInternal); err != nil {
+			return 0, fmt.Errorf("getting is_internal field: %w", err)
+		}
+
+		var isHidden bool
+		if err := a.dp.GetIfExist(ctx, fqid+"/is_hidden", &isHidden); err != nil {
+			return 0, fmt.Errorf("getting is_hidden field: %w", err)
+		}
+
+		v := agendaCommon
+		if isInternal {
+			v = agendaInternal
+		}
+		if isHidden {
+			v = agendaHidden
+		}
+
+		path = append(path, current)
+		own = append(own, v)
+
+		var parentID int
+		if err := a.dp.GetIfExist(ctx, fqid+"/parent_id", &parentID); err != nil {
+			return 0, fmt.Errorf("getting parent_id field: %w", err)
+		}
+		current = parentID
+	}
+
+	// Walk back from the root to the item.
+	v := inherited
+	for i := len(path) - 1; i >= 0; i-- {
+		if own[i] > v {
+			v = own[i]
+		}
+		cache[path[i]] = v
+	}
+	return cache[id], nil
+}
+
+// filterChildIDs removes the ids of all children from the value of the field
+// child_ids, that the user can not see.
+func (a *agendaItem) filterChildIDs(ctx context.Context, userID int, fqfield perm.FQField, value json.RawMessage) (json.RawMessage, error) {
+	var childIDs []int
+	if err := json.Unmarshal(value, &childIDs); err != nil {
+		return nil, perm.InvalidPayloadf("value of %s is not a list of ids: %v", fqfield, err)
+	}
+
+	meetingID, err := a.dp.MeetingFromModel(ctx, fqfield.FQID())
+	if err != nil {
+		return nil, fmt.Errorf("getting meeting id for %s: %w", fqfield, err)
+	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
+
+	perms, err := perm.New(ctx, a.dp, userID, meetingID)
+	if err != nil {
+		return nil, fmt.Errorf("getting perms for meeting %d: %w", meetingID, err)
+	}
+
+	if err := a.prefetchAncestors(ctx, childIDs); err != nil {
+		return nil, fmt.Errorf("prefetching children: %w", err)
+	}
+
+	visibilities := make(map[int]agendaVisibility)
+	visible := make([]int, 0, len(childIDs))
+	for _, childID := range childIDs {
+		v, err := a.visibility(ctx, childID, visibilities)
+		if err != nil {
+			return nil, fmt.Errorf("getting visibility of child %d: %w", childID, err)
+		}
+
+		if !perms.Has(v.requiredPerm()) {
+			perm.LogNotAllowedf(ctx, "The child agenda_item/%d can not be seen", childID)
+			continue
+		}
+		visible = append(visible, childID)
+	}
+
+	return json.Marshal(visible)
+}
+
+// prefetchAncestors loads the fields, that are needed to calculate the
+// visibility of the agenda items and all their ancestors.
+//
+// The items of each level of the tree are loaded with one request to the
+// datastore.
+func (a *agendaItem) prefetchAncestors(ctx context.Context, ids []int) error {
+	seen := make(map[int]bool)
+	for len(ids) > 0 {
+		var level []int
+		var keys []string
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			level = append(level, id)
+
+			fqid := fmt.Sprintf("agenda_item/%d", id)
+			keys = append(keys, fqid+"/is_internal", fqid+"/is_hidden", fqid+"/parent_id")
+		}
+
+		if err := a.dp.Prefetch(ctx, keys...); err != nil {
+			return fmt.Errorf("prefetching agenda items: %w", err)
+		}
+
+		ids = nil
+		for _, id := range level {
+			var parentID int
+			if err := a.dp.GetIfExist(ctx, fmt.Sprintf("agenda_item/%d/parent_id", id), &parentID); err != nil {
+				return fmt.Errorf("getting parent_id field: %w", err)
+			}
+
+			if parentID != 0 && !seen[parentID] {
+				ids = append(ids, parentID)
+			}
+		}
+	}
+	return nil
+}
+
 type meetingFields struct {
 	meetingID int
 	perm      *perm.Permission
@@ -27,6 +27,7 @@ func (a *assignment) candidateCreate(ctx context.Context, userID int, payload ma
 	if err != nil {
 		return false, fmt.Errorf("getting meetingID: %w", err)
 	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
 
 	permissions, err := perm.New(ctx, a.dp, userID, meetingID)
 	if err != nil {
@@ -43,13 +44,13 @@ func (a *assignment) candidateCreate(ctx context.Context, userID int, payload ma
 	}
 
 	if phase != "search" {
-		perm.LogNotAllowedf("Assignment is already in phase %s. No new candidates allowed.", phase)
+		perm.LogNotAllowedf(ctx, "Assignment is already in phase %s. No new candidates allowed.", phase)
 		return false, nil
 	}
 
 	var cid int
 	if err := json.Unmarshal(payload["user_id"], &cid); err != nil {
-		return false, fmt.Errorf("getting user_id from payload: %w", err)
+		return false, perm.InvalidPayloadf("getting user_id: %v", err)
 	}
 
 	requiredPerm := perm.AssignmentCanNominateOther
@@ -61,7 +62,7 @@ func (a *assignment) candidateCreate(ctx context.Context, userID int, payload ma
 		return true, nil
 	}
 
-	perm.LogNotAllowedf("User %d does not have the permission %s", userID, requiredPerm)
+	perm.LogNotAllowedf(ctx, "User %d does not have the permission %s", userID, requiredPerm)
 	return false, nil
 }
 
@@ -70,6 +71,7 @@ func (a *assignment) candidateDelete(ctx context.Context, userID int, payload ma
 	if err != nil {
 		return false, fmt.Errorf("getting meetingID: %w", err)
 	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
 
 	permissions, err := perm.New(ctx, a.dp, userID, meetingID)
 	if err != nil {
@@ -86,13 +88,13 @@ func (a *assignment) candidateDelete(ctx context.Context, userID int, payload ma
 	}
 
 	if phase != "search" {
-		perm.LogNotAllowedf("Assignment is already in phase %S. You can not remove yourself anymore.", phase)
+		perm.LogNotAllowedf(ctx, "Assignment is already in phase %s. You can not remove yourself anymore.", phase)
 		return false, nil
 	}
 
 	var cid int
 	if err := json.Unmarshal(payload["user_id"], &cid); err != nil {
-		return false, fmt.Errorf("getting user_id from payload: %w", err)
+		return false, perm.InvalidPayloadf("getting user_id: %v", err)
 	}
 
 	if userID == cid && permissions.Has(perm.AssignmentCanNominateSelf) {
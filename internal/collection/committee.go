@@ -13,6 +13,9 @@ func Committee(dp dataprovider.DataProvider) perm.ConnecterFunc {
 	c := &committee{dp: dp}
 	return func(s perm.HandlerStore) {
 		s.RegisterRestricter("committee", perm.CollectionFunc(c.read))
+
+		s.RegisterDependency("committee", "member_ids", usersInValue)
+		s.RegisterDependency("committee", "manager_ids", usersInValue)
 	}
 }
 
@@ -0,0 +1,161 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
+	"github.com/OpenSlides/openslides-permission-service/internal/perm"
+)
+
+// changedUser returns the user of the changed key.
+//
+// It can only be used for keys of the collection user.
+func changedUser(ctx context.Context, key perm.FQField, updated map[string]json.RawMessage) ([]int, error) {
+	return []int{key.ID}, nil
+}
+
+// usersInValue returns the user ids from the changed value.
+//
+// It can be used for keys that contain a user id or a list of user ids.
+func usersInValue(ctx context.Context, key perm.FQField, updated map[string]json.RawMessage) ([]int, error) {
+	value := updated[key.String()]
+	if value == nil {
+		return nil, nil
+	}
+
+	var ids []int
+	if err := json.Unmarshal(value, &ids); err != nil {
+		var id int
+		if err := json.Unmarshal(value, &id); err != nil {
+			return nil, fmt.Errorf("decoding value of %s: %w", key, err)
+		}
+		ids = []int{id}
+	}
+	return ids, nil
+}
+
+// groupUsers returns the users of the group of the changed key.
+//
+// It can only be used for keys of the collection group.
+func groupUsers(dp dataprovider.DataProvider) perm.AffectedUsersFunc {
+	return func(ctx context.Context, key perm.FQField, updated map[string]json.RawMessage) ([]int, error) {
+		var userIDs []int
+		if err := dp.GetIfExist(ctx, fmt.Sprintf("group/%d/user_ids", key.ID), &userIDs); err != nil {
+			return nil, fmt.Errorf("getting users of group %d: %w", key.ID, err)
+		}
+		return userIDs, nil
+	}
+}
+
+// userGroupUsers returns the changed user and all users of the meeting, in
+// that the groups of the user changed.
+//
+// It can only be used for the field user/group_$. For the field group_$_ids,
+// that lists the meetings, only the changed user is returned.
+func userGroupUsers(dp dataprovider.DataProvider) perm.AffectedUsersFunc {
+	return func(ctx context.Context, key perm.FQField, updated map[string]json.RawMessage) ([]int, error) {
+		replacement := strings.TrimSuffix(strings.TrimPrefix(key.Field, "group_$"), "_ids")
+		if replacement == "" {
+			return []int{key.ID}, nil
+		}
+
+		meetingID, err := strconv.Atoi(replacement)
+		if err != nil {
+			return nil, fmt.Errorf("invalid meeting id in %s: %w", key, err)
+		}
+
+		userIDs, err := usersOfMeeting(ctx, dp, meetingID)
+		if err != nil {
+			return nil, err
+		}
+		return append(userIDs, key.ID), nil
+	}
+}
+
+// meetingUsers returns all users of the meeting of the changed object
+// including the anonymous user.
+//
+// If the object was deleted with this update, the meeting is found by
+// deletedFromMeetings.
+func meetingUsers(dp dataprovider.DataProvider) perm.AffectedUsersFunc {
+	return func(ctx context.Context, key perm.FQField, updated map[string]json.RawMessage) ([]int, error) {
+		if key.Collection == "meeting" {
+			return usersOfMeeting(ctx, dp, key.ID)
+		}
+
+		var meetingID int
+		if err := dp.GetIfExist(ctx, key.FQID()+"/meeting_id", &meetingID); err != nil {
+			return nil, fmt.Errorf("getting meeting of %s: %w", key.FQID(), err)
+		}
+
+		meetingIDs := []int{meetingID}
+		if meetingID == 0 {
+			ids, err := deletedFromMeetings(key, updated)
+			if err != nil {
+				return nil, fmt.Errorf("getting meeting of deleted %s: %w", key.FQID(), err)
+			}
+			meetingIDs = ids
+		}
+
+		var userIDs []int
+		for _, id := range meetingIDs {
+			ids, err := usersOfMeeting(ctx, dp, id)
+			if err != nil {
+				return nil, err
+			}
+			userIDs = append(userIDs, ids...)
+		}
+		return userIDs, nil
+	}
+}
+
+// deletedFromMeetings returns the meetings, from that the object of the key was
+// removed with this update.
+//
+// These are the meetings, whose field <collection>_ids is in updated, but does
+// not contain the object anymore.
+func deletedFromMeetings(key perm.FQField, updated map[string]json.RawMessage) ([]int, error) {
+	listField := key.Collection + "_ids"
+
+	var meetingIDs []int
+	for k, value := range updated {
+		fqfield, err := perm.ParseFQField(k)
+		if err != nil || fqfield.Collection != "meeting" || fqfield.Field != listField {
+			continue
+		}
+
+		var ids []int
+		if value != nil {
+			if err := json.Unmarshal(value, &ids); err != nil {
+				return nil, fmt.Errorf("decoding value of %s: %w", k, err)
+			}
+		}
+
+		if !containsInt(ids, key.ID) {
+			meetingIDs = append(meetingIDs, fqfield.ID)
+		}
+	}
+	return meetingIDs, nil
+}
+
+// usersOfMeeting returns all users of a meeting including the anonymous user.
+func usersOfMeeting(ctx context.Context, dp dataprovider.DataProvider, meetingID int) ([]int, error) {
+	var userIDs []int
+	if err := dp.GetIfExist(ctx, fmt.Sprintf("meeting/%d/user_ids", meetingID), &userIDs); err != nil {
+		return nil, fmt.Errorf("getting users of meeting %d: %w", meetingID, err)
+	}
+	return append(userIDs, 0), nil
+}
+
+// registerMeetingDependencies registers the given fields of a collection to
+// affect all users of the meeting.
+func registerMeetingDependencies(s perm.HandlerStore, dp dataprovider.DataProvider, collection string, fields ...string) {
+	f := meetingUsers(dp)
+	for _, field := range fields {
+		s.RegisterDependency(collection, field, f)
+	}
+}
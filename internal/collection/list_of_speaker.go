@@ -23,6 +23,8 @@ func ListOfSpeaker(dp dataprovider.DataProvider) perm.ConnecterFunc {
 
 		s.RegisterAction("list_of_speakers.delete", perm.ActionFunc(l.listDelete))
 		s.RegisterRestricter("list_of_speakers", perm.CollectionFunc(l.listRead))
+
+		s.RegisterDependency("speaker", "user_id", usersInValue)
 	}
 }
 
@@ -35,6 +37,7 @@ func (l *listOfSpeaker) speakerCreate(ctx context.Context, userID int, payload m
 	if err := l.dp.Get(ctx, fmt.Sprintf("list_of_speakers/%s/meeting_id", payload["list_of_speakers_id"]), &meetingID); err != nil {
 		return false, fmt.Errorf("getting meeting id: %w", err)
 	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
 
 	perms, err := perm.New(ctx, l.dp, userID, meetingID)
 	if err != nil {
@@ -43,7 +46,7 @@ func (l *listOfSpeaker) speakerCreate(ctx context.Context, userID int, payload m
 
 	puid, err := strconv.Atoi(string(payload["user_id"]))
 	if err != nil {
-		return false, fmt.Errorf("invalid value in payload['user_id']: %s", payload["user_id"])
+		return false, perm.InvalidPayloadf("invalid value in user_id: %s", payload["user_id"])
 	}
 
 	requiredPerm := perm.ListOfSpeakersCanManage
@@ -54,7 +57,7 @@ func (l *listOfSpeaker) speakerCreate(ctx context.Context, userID int, payload m
 	if perms.Has(requiredPerm) {
 		return true, nil
 	}
-	perm.LogNotAllowedf("User %d can not set user %d on the list of speaker.", userID, puid)
+	perm.LogNotAllowedf(ctx, "User %d can not set user %d on the list of speaker.", userID, puid)
 	return false, nil
 }
 
@@ -111,7 +114,7 @@ func (l *listOfSpeaker) speakerRead(ctx context.Context, userID int, fqfields []
 }
 
 func (l *listOfSpeaker) listDelete(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
-	perm.LogNotAllowedf("list_of_speaker.delete is an internal action.")
+	perm.LogNotAllowedf(ctx, "list_of_speaker.delete is an internal action.")
 	return false, nil
 }
 
@@ -18,6 +18,8 @@ func Mediafile(dp dataprovider.DataProvider) perm.ConnecterFunc {
 		s.RegisterRestricter("mediafile", perm.CollectionFunc(m.read))
 
 		s.RegisterAction("mediafile.can_see_mediafile", perm.ActionFunc(m.canSeeAction))
+
+		registerMeetingDependencies(s, dp, "mediafile", "is_public", "inherited_access_group_ids", "used_as_logo_$", "used_as_font_$", "current_projector_ids")
 	}
 }
 
@@ -32,6 +34,7 @@ func (m *mediafile) read(ctx context.Context, userID int, fqfields []perm.FQFiel
 		if err != nil {
 			return false, fmt.Errorf("getting meetingID from model %s: %w", fqid, err)
 		}
+		ctx := perm.WithLogMeeting(ctx, meetingID)
 
 		perms, err := perm.New(ctx, m.dp, userID, meetingID)
 		if err != nil {
@@ -50,6 +53,7 @@ func (m *mediafile) read(ctx context.Context, userID int, fqfields []perm.FQFiel
 		}
 
 		if !isPublic {
+			perm.LogNotAllowedf(ctx, "Mediafile %d is not public", fqfield.ID)
 			return false, nil
 		}
 
@@ -60,7 +64,7 @@ func (m *mediafile) read(ctx context.Context, userID int, fqfields []perm.FQFiel
 func (m *mediafile) canSeeAction(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
 	var mediafileID int
 	if err := json.Unmarshal(payload["id"], &mediafileID); err != nil {
-		return false, fmt.Errorf("no valid id in payload")
+		return false, perm.InvalidPayloadf("no valid id")
 	}
 
 	fqid := "mediafile/" + strconv.Itoa(mediafileID)
@@ -68,6 +72,7 @@ func (m *mediafile) canSeeAction(ctx context.Context, userID int, payload map[st
 	if err != nil {
 		return false, fmt.Errorf("getting meeting id for %s: %w", fqid, err)
 	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
 
 	perms, err := perm.New(ctx, m.dp, userID, meetingID)
 	if err != nil {
@@ -18,6 +18,10 @@ func Meeting(dp dataprovider.DataProvider) perm.ConnecterFunc {
 		s.RegisterAction("meeting.create", perm.ActionFunc(m.update))
 		s.RegisterAction("meeting.update", perm.ActionFunc(m.update))
 		s.RegisterAction("meeting.delete", perm.ActionFunc(m.update))
+
+		registerMeetingDependencies(s, dp, "meeting", "admin_group_id", "default_group_id", "enable_anonymous")
+		s.RegisterDependency("group", "permissions", groupUsers(dp))
+		s.RegisterDependency("group", "user_ids", usersInValue)
 	}
 }
 
@@ -28,7 +32,7 @@ type meeting struct {
 func (m *meeting) update(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
 	committeeID, err := strconv.Atoi(string(payload["committee_id"]))
 	if err != nil {
-		return false, fmt.Errorf("invalid payload: %w", err)
+		return false, perm.InvalidPayloadf("invalid committee_id: %v", err)
 	}
 
 	var managerIDs []int
@@ -41,6 +45,8 @@ func (m *meeting) update(ctx context.Context, userID int, payload map[string]jso
 			return true, nil
 		}
 	}
+
+	perm.LogNotAllowedf(ctx, "User %d is not a manager of committee %d", userID, committeeID)
 	return false, nil
 }
 
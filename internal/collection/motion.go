@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
 	"github.com/OpenSlides/openslides-permission-service/internal/perm"
@@ -15,10 +16,12 @@ func Motion(dp dataprovider.DataProvider) perm.ConnecterFunc {
 	m := &motion{dp}
 	return func(s perm.HandlerStore) {
 		s.RegisterAction("motion.delete", m.modify(perm.MotionCanManage))
-		s.RegisterAction("motion.set_state", m.modify(perm.MotionCanManageMetadata))
+		s.RegisterAction("motion.set_state", perm.ActionFunc(m.setState))
 		s.RegisterAction("motion.create", m.create())
 		s.RegisterAction("motion_submitter.create", m.submitterCreate())
 		s.RegisterAction("motion.update", m.modify(perm.MotionCanManage))
+		s.RegisterAction("motion.support", perm.ActionFunc(m.support))
+		s.RegisterAction("motion.unsupport", perm.ActionFunc(m.support))
 		s.RegisterAction("motion_comment.delete", perm.ActionFunc(m.commentModify))
 		s.RegisterAction("motion_comment.update", perm.ActionFunc(m.commentModify))
 		s.RegisterAction("motion_comment.create", perm.ActionFunc(m.commentCreate))
@@ -29,6 +32,13 @@ func Motion(dp dataprovider.DataProvider) perm.ConnecterFunc {
 		s.RegisterRestricter("motion_change_recommendation", m.readChangeRecommendation())
 		s.RegisterRestricter("motion_comment_section", perm.CollectionFunc(m.readCommentSection))
 		s.RegisterRestricter("motion_comment", perm.CollectionFunc(m.readComment))
+
+		registerMeetingDependencies(s, dp, "motion", "state_id", "submitter_ids")
+		registerMeetingDependencies(s, dp, "motion_state", "restrictions")
+		registerMeetingDependencies(s, dp, "motion_block", "internal")
+		registerMeetingDependencies(s, dp, "motion_change_recommendation", "internal")
+		registerMeetingDependencies(s, dp, "motion_comment_section", "read_group_ids")
+		s.RegisterDependency("motion_submitter", "user_id", usersInValue)
 	}
 }
 
@@ -59,8 +69,9 @@ func (m *motion) create() perm.ActionFunc {
 	return func(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
 		meetingID, err := strconv.Atoi(string(payload["meeting_id"]))
 		if err != nil {
-			return false, fmt.Errorf("invalid field meeting_id in payload: %w", err)
+			return false, perm.InvalidPayloadf("invalid field meeting_id: %v", err)
 		}
+		ctx = perm.WithLogMeeting(ctx, meetingID)
 
 		perms, err := perm.New(ctx, m.dp, userID, meetingID)
 		if err != nil {
@@ -79,13 +90,13 @@ func (m *motion) create() perm.ActionFunc {
 		}
 
 		if !perms.Has(requiredPerm) {
-			perm.LogNotAllowedf("User %d does not have permission %s", userID, requiredPerm)
+			perm.LogNotAllowedf(ctx, "User %d does not have permission %s", userID, requiredPerm)
 			return false, nil
 		}
 
 		for e := range payload {
 			if !aList[string(e)] {
-				perm.LogNotAllowedf("Field `%s` is forbidden for non manager.", e)
+				perm.LogNotAllowedf(ctx, "Field `%s` is forbidden for non manager.", e)
 				return false, nil
 			}
 		}
@@ -101,6 +112,7 @@ func (m *motion) modify(managePerm perm.TPermission) perm.ActionFunc {
 		if err != nil {
 			return false, fmt.Errorf("getting meeting for %s: %w", motionFQID, err)
 		}
+		ctx = perm.WithLogMeeting(ctx, meetingID)
 
 		perms, err := perm.New(ctx, m.dp, userID, meetingID)
 		if err != nil {
@@ -117,14 +129,14 @@ func (m *motion) modify(managePerm perm.TPermission) perm.ActionFunc {
 			case "id", "title", "text", "reason", "amendment_paragraphs":
 				continue
 			default:
-				perm.LogNotAllowedf("Non managers can not modify field %s", k)
+				perm.LogNotAllowedf(ctx, "Non managers can not modify field %s", k)
 				return false, nil
 			}
 		}
 
 		motionID, err := strconv.Atoi(string(payload["id"]))
 		if err != nil {
-			return false, fmt.Errorf("invalid payload: %w", err)
+			return false, perm.InvalidPayloadf("invalid id: %v", err)
 		}
 
 		b, err := canSeeMotion(ctx, m.dp, userID, motionID, perms)
@@ -133,29 +145,17 @@ func (m *motion) modify(managePerm perm.TPermission) perm.ActionFunc {
 		}
 
 		if !b {
-			perm.LogNotAllowedf("User %d can not see the motion", userID)
+			perm.LogNotAllowedf(ctx, "User %d can not see the motion", userID)
 			return false, nil
 		}
 
-		var submitterIDs []int
-		if err := m.dp.GetIfExist(ctx, motionFQID+"/submitter_ids", &submitterIDs); err != nil {
-			return false, fmt.Errorf("getting submitter ids: %w", err)
-		}
-
-		var isSubmitter bool
-		for _, sid := range submitterIDs {
-			var sUserID int
-			if err := m.dp.Get(ctx, fmt.Sprintf("motion_submitter/%d/user_id", sid), &sUserID); err != nil {
-				return false, fmt.Errorf("getting userid of sumitter %d: %w", sid, err)
-			}
-			if sUserID == userID {
-				isSubmitter = true
-				break
-			}
+		isSubmitter, err := m.isSubmitter(ctx, userID, motionFQID)
+		if err != nil {
+			return false, fmt.Errorf("checking submitter: %w", err)
 		}
 
 		if !isSubmitter {
-			perm.LogNotAllowedf("User %d is not a manager and not a submitter of %s", userID, motionFQID)
+			perm.LogNotAllowedf(ctx, "User %d is not a manager and not a submitter of %s", userID, motionFQID)
 			return false, nil
 		}
 
@@ -170,7 +170,7 @@ func (m *motion) modify(managePerm perm.TPermission) perm.ActionFunc {
 		}
 
 		if !allowSubmitterEdit {
-			perm.LogNotAllowedf("Motion state does not allow submitter edites")
+			perm.LogNotAllowedf(ctx, "Motion state does not allow submitter edites")
 			return false, nil
 		}
 
@@ -178,12 +178,251 @@ func (m *motion) modify(managePerm perm.TPermission) perm.ActionFunc {
 	}
 }
 
+// setState handles motion.set_state.
+//
+// The new state has to be in the workflow of the motion and has to be a next
+// or previous state of the current state. Users with the permission
+// motion.can_manage_metadata can set every such state. Other users have to be
+// a submitter of the motion and the current state has to allow submitter
+// edits.
+func (m *motion) setState(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
+	var motionID int
+	if err := json.Unmarshal(payload["id"], &motionID); err != nil {
+		return false, perm.InvalidPayloadf("no id: %v", err)
+	}
+
+	var newStateID int
+	if err := json.Unmarshal(payload["state_id"], &newStateID); err != nil {
+		return false, perm.InvalidPayloadf("no state_id: %v", err)
+	}
+
+	motionFQID := fmt.Sprintf("motion/%d", motionID)
+	meetingID, err := m.dp.MeetingFromModel(ctx, motionFQID)
+	if err != nil {
+		return false, fmt.Errorf("getting meeting for %s: %w", motionFQID, err)
+	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
+
+	var stateID int
+	if err := m.dp.Get(ctx, motionFQID+"/state_id", &stateID); err != nil {
+		return false, fmt.Errorf("getting state id: %w", err)
+	}
+
+	sameWorkflow, err := m.sameWorkflow(ctx, stateID, newStateID)
+	if err != nil {
+		return false, fmt.Errorf("checking workflow: %w", err)
+	}
+
+	if !sameWorkflow {
+		return false, nil
+	}
+
+	perms, err := perm.New(ctx, m.dp, userID, meetingID)
+	if err != nil {
+		return false, fmt.Errorf("getting perms: %w", err)
+	}
+
+	if perms.Has(perm.MotionCanManageMetadata) {
+		return true, nil
+	}
+
+	reachable, err := m.stateReachable(ctx, stateID, newStateID)
+	if err != nil {
+		return false, fmt.Errorf("checking state transition: %w", err)
+	}
+
+	if !reachable {
+		return false, nil
+	}
+
+	for k := range payload {
+		if k != "id" && k != "state_id" {
+			perm.LogNotAllowedf(ctx, "Non managers can not modify field %s", k)
+			return false, nil
+		}
+	}
+
+	canSee, err := canSeeMotion(ctx, m.dp, userID, motionID, perms)
+	if err != nil {
+		return false, fmt.Errorf("getting canSee: %w", err)
+	}
+
+	if !canSee {
+		perm.LogNotAllowedf(ctx, "User %d can not see the motion", userID)
+		return false, nil
+	}
+
+	isSubmitter, err := m.isSubmitter(ctx, userID, motionFQID)
+	if err != nil {
+		return false, fmt.Errorf("checking submitter: %w", err)
+	}
+
+	if !isSubmitter {
+		perm.LogNotAllowedf(ctx, "User %d is not a manager and not a submitter of %s", userID, motionFQID)
+		return false, nil
+	}
+
+	var allowSubmitterEdit bool
+	if err := m.dp.GetIfExist(ctx, fmt.Sprintf("motion_state/%d/allow_submitter_edit", stateID), &allowSubmitterEdit); err != nil {
+		return false, fmt.Errorf("getting allow_submitter_edit: %w", err)
+	}
+
+	if !allowSubmitterEdit {
+		perm.LogNotAllowedf(ctx, "Motion state %d does not allow submitters to change the state", stateID)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// sameWorkflow tells, if the state newStateID is in the same workflow as the
+// state stateID.
+func (m *motion) sameWorkflow(ctx context.Context, stateID, newStateID int) (bool, error) {
+	var workflowID, newWorkflowID int
+	if err := m.dp.Get(ctx, fmt.Sprintf("motion_state/%d/workflow_id", stateID), &workflowID); err != nil {
+		return false, fmt.Errorf("getting workflow of state %d: %w", stateID, err)
+	}
+
+	if err := m.dp.GetIfExist(ctx, fmt.Sprintf("motion_state/%d/workflow_id", newStateID), &newWorkflowID); err != nil {
+		return false, fmt.Errorf("getting workflow of state %d: %w", newStateID, err)
+	}
+
+	if newWorkflowID != workflowID {
+		perm.LogNotAllowedf(ctx, "State %d is not in the workflow %d of the motion", newStateID, workflowID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// stateReachable tells, if the state newStateID is one of the next or previous
+// states of the state stateID.
+func (m *motion) stateReachable(ctx context.Context, stateID, newStateID int) (bool, error) {
+	var nextIDs, previousIDs []int
+	if err := m.dp.GetIfExist(ctx, fmt.Sprintf("motion_state/%d/next_state_ids", stateID), &nextIDs); err != nil {
+		return false, fmt.Errorf("getting next states of state %d: %w", stateID, err)
+	}
+
+	if err := m.dp.GetIfExist(ctx, fmt.Sprintf("motion_state/%d/previous_state_ids", stateID), &previousIDs); err != nil {
+		return false, fmt.Errorf("getting previous states of state %d: %w", stateID, err)
+	}
+
+	if !containsInt(nextIDs, newStateID) && !containsInt(previousIDs, newStateID) {
+		perm.LogNotAllowedf(ctx, "State %d can not be reached from state %d", newStateID, stateID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// isSubmitter tells, if the user is a submitter of the motion.
+func (m *motion) isSubmitter(ctx context.Context, userID int, motionFQID string) (bool, error) {
+	var submitterIDs []int
+	if err := m.dp.GetIfExist(ctx, motionFQID+"/submitter_ids", &submitterIDs); err != nil {
+		return false, fmt.Errorf("getting submitter ids: %w", err)
+	}
+
+	for _, sid := range submitterIDs {
+		var sUserID int
+		if err := m.dp.Get(ctx, fmt.Sprintf("motion_submitter/%d/user_id", sid), &sUserID); err != nil {
+			return false, fmt.Errorf("getting userid of sumitter %d: %w", sid, err)
+		}
+		if sUserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// support handles motion.support and motion.unsupport.
+//
+// The payload has the field motion_id and optional the field user_id. A user
+// can only support or unsupport a motion for himself.
+func (m *motion) support(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
+	for k := range payload {
+		if k != "motion_id" && k != "user_id" {
+			perm.LogNotAllowedf(ctx, "Field `%s` is not allowed to support a motion", k)
+			return false, nil
+		}
+	}
+
+	var motionID int
+	if err := json.Unmarshal(payload["motion_id"], &motionID); err != nil {
+		return false, perm.InvalidPayloadf("no motion_id: %v", err)
+	}
+
+	if raw, ok := payload["user_id"]; ok {
+		var supporterID int
+		if err := json.Unmarshal(raw, &supporterID); err != nil {
+			return false, perm.InvalidPayloadf("invalid user_id: %v", err)
+		}
+
+		if supporterID != userID {
+			perm.LogNotAllowedf(ctx, "User %d can only support motions for himself, not for user %d", userID, supporterID)
+			return false, nil
+		}
+	}
+
+	motionFQID := fmt.Sprintf("motion/%d", motionID)
+	meetingID, err := m.dp.MeetingFromModel(ctx, motionFQID)
+	if err != nil {
+		return false, fmt.Errorf("getting meeting for %s: %w", motionFQID, err)
+	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
+
+	var minSupporters int
+	if err := m.dp.GetIfExist(ctx, fmt.Sprintf("meeting/%d/motions_supporters_min_amount", meetingID), &minSupporters); err != nil {
+		return false, fmt.Errorf("getting motions_supporters_min_amount: %w", err)
+	}
+
+	if minSupporters <= 0 {
+		perm.LogNotAllowedf(ctx, "Supporting motions is disabled in meeting %d", meetingID)
+		return false, nil
+	}
+
+	perms, err := perm.New(ctx, m.dp, userID, meetingID)
+	if err != nil {
+		return false, fmt.Errorf("getting perms: %w", err)
+	}
+
+	if !perms.Has(perm.MotionCanSupport) {
+		perm.LogNotAllowedf(ctx, "User %d does not have the permission %s", userID, perm.MotionCanSupport)
+		return false, nil
+	}
+
+	var stateID int
+	if err := m.dp.Get(ctx, motionFQID+"/state_id", &stateID); err != nil {
+		return false, fmt.Errorf("getting state id: %w", err)
+	}
+
+	var allowSupport bool
+	if err := m.dp.GetIfExist(ctx, fmt.Sprintf("motion_state/%d/allow_support", stateID), &allowSupport); err != nil {
+		return false, fmt.Errorf("getting allow_support: %w", err)
+	}
+
+	if !allowSupport {
+		perm.LogNotAllowedf(ctx, "Motion state %d does not allow support", stateID)
+		return false, nil
+	}
+
+	canSee, err := canSeeMotion(ctx, m.dp, userID, motionID, perms)
+	if err != nil {
+		return false, fmt.Errorf("getting canSee: %w", err)
+	}
+
+	if !canSee {
+		perm.LogNotAllowedf(ctx, "User %d can not see the motion", userID)
+		return false, nil
+	}
+
+	return true, nil
+}
+
 func canSeeMotion(ctx context.Context, dp dataprovider.DataProvider, userID int, motionID int, perms *perm.Permission) (bool, error) {
 	if perms.Has(perm.MotionCanManage) {
 		return true, nil
 	}
 
 	if !perms.Has(perm.MotionCanSee) {
+		perm.LogNotAllowedf(ctx, "User %d does not have the permission %s", userID, perm.MotionCanSee)
 		return false, nil
 	}
 
@@ -229,15 +468,30 @@ func canSeeMotion(ctx context.Context, dp dataprovider.DataProvider, userID int,
 			}
 		}
 	}
+
+	perm.LogNotAllowedf(ctx, "State restriction %s of motion %d not satisfied", strings.Join(restriction, " or "), motionID)
 	return false, nil
 }
 
 func (m *motion) readMotion(ctx context.Context, userID int, fqfields []perm.FQField, result map[string]bool) error {
+	if err := prefetch(ctx, m.dp, fqfields, "meeting_id", "state_id", "submitter_ids"); err != nil {
+		return fmt.Errorf("prefetching motions: %w", err)
+	}
+
+	if err := prefetchRelated(ctx, m.dp, fqfields, "state_id", "motion_state", "restrictions"); err != nil {
+		return fmt.Errorf("prefetching motion states: %w", err)
+	}
+
+	if err := prefetchPerms(ctx, m.dp, userID, fqfields); err != nil {
+		return fmt.Errorf("prefetching permissions: %w", err)
+	}
+
 	return perm.AllFields(fqfields, result, func(fqfield perm.FQField) (bool, error) {
 		meetingID, err := m.dp.MeetingFromModel(ctx, fmt.Sprintf("motion/%d", fqfield.ID))
 		if err != nil {
 			return false, fmt.Errorf("getting meetingID from motion: %w", err)
 		}
+		ctx := perm.WithLogMeeting(ctx, meetingID)
 
 		perms, err := perm.New(ctx, m.dp, userID, meetingID)
 		if err != nil {
@@ -275,6 +529,7 @@ func (m *motion) readSubmitter(ctx context.Context, userID int, fqfields []perm.
 		if err != nil {
 			return false, fmt.Errorf("getting meetingID from motion: %w", err)
 		}
+		ctx := perm.WithLogMeeting(ctx, meetingID)
 
 		perms, err := perm.New(ctx, m.dp, userID, meetingID)
 		if err != nil {
@@ -292,6 +547,7 @@ func (m *motion) readBlock() perm.CollectionFunc {
 			if err != nil {
 				return false, fmt.Errorf("getting meetingID from model %s: %w", fqid, err)
 			}
+			ctx := perm.WithLogMeeting(ctx, meetingID)
 
 			perms, err := perm.New(ctx, m.dp, userID, meetingID)
 			if err != nil {
@@ -315,6 +571,7 @@ func (m *motion) readBlock() perm.CollectionFunc {
 				return true, nil
 			}
 
+			perm.LogNotAllowedf(ctx, "Motion block %d is internal", fqfield.ID)
 			return false, nil
 		})
 	}
@@ -328,6 +585,7 @@ func (m *motion) readChangeRecommendation() perm.CollectionFunc {
 			if err != nil {
 				return false, fmt.Errorf("getting meetingID from model %s: %w", fqid, err)
 			}
+			ctx := perm.WithLogMeeting(ctx, meetingID)
 
 			perms, err := perm.New(ctx, m.dp, userID, meetingID)
 			if err != nil {
@@ -360,7 +618,8 @@ func (m *motion) readChangeRecommendation() perm.CollectionFunc {
 				return true, nil
 			}
 
-			return perms.Has(perm.MotionCanManage), nil
+			perm.LogNotAllowedf(ctx, "Motion change recommendation %d is internal", fqfield.ID)
+			return false, nil
 		})
 	}
 }
@@ -371,6 +630,7 @@ func (m *motion) canSeeCommentSection(ctx context.Context, userID, id int) (bool
 	if err != nil {
 		return false, fmt.Errorf("getting meetingID from model %s: %w", fqid, err)
 	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
 
 	perms, err := perm.New(ctx, m.dp, userID, meetingID)
 	if err != nil {
@@ -403,6 +663,8 @@ func (m *motion) canSeeCommentSection(ctx context.Context, userID, id int) (bool
 			return true, nil
 		}
 	}
+
+	perm.LogNotAllowedf(ctx, "User %d is not in a read group of comment section %d", userID, id)
 	return false, nil
 }
 
@@ -418,6 +680,7 @@ func (m *motion) commentAction(ctx context.Context, userID int, sectionID int) (
 	if err != nil {
 		return false, fmt.Errorf("getting meetingID from model %s: %w", fqid, err)
 	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
 
 	perms, err := perm.New(ctx, m.dp, userID, meetingID)
 	if err != nil {
@@ -442,6 +705,7 @@ func (m *motion) commentAction(ctx context.Context, userID int, sectionID int) (
 		}
 
 		if !inGroup {
+			perm.LogNotAllowedf(ctx, "User %d is not in a group of %s%s", userID, fqid, field)
 			return false, nil
 		}
 	}
@@ -460,7 +724,7 @@ func (m *motion) commentModify(ctx context.Context, userID int, payload map[stri
 func (m *motion) commentCreate(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
 	sectionID, err := strconv.Atoi(string(payload["section_id"]))
 	if err != nil {
-		return false, fmt.Errorf("invalid section_id: %s", payload["section_id"])
+		return false, perm.InvalidPayloadf("invalid section_id: %s", payload["section_id"])
 	}
 
 	return m.commentAction(ctx, userID, sectionID)
@@ -18,6 +18,8 @@ func PersonalNote(dp dataprovider.DataProvider) perm.ConnecterFunc {
 		s.RegisterAction("personal_note.delete", perm.ActionFunc(p.modify))
 
 		s.RegisterRestricter("personal_note", p)
+
+		s.RegisterDependency("personal_note", "user_id", usersInValue)
 	}
 }
 
@@ -27,7 +29,7 @@ type personalNote struct {
 
 func (p personalNote) create(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
 	if userID == 0 {
-		perm.LogNotAllowedf("Anonymous can not create personal notes.")
+		perm.LogNotAllowedf(ctx, "Anonymous can not create personal notes.")
 		return false, nil
 	}
 	return true, nil
@@ -41,7 +43,7 @@ func (p personalNote) modify(ctx context.Context, userID int, payload map[string
 	}
 
 	if noteUserID != userID {
-		perm.LogNotAllowedf("Note belongs to a different user.")
+		perm.LogNotAllowedf(ctx, "Note belongs to a different user.")
 		return false, nil
 	}
 	return true, nil
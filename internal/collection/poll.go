@@ -19,9 +19,19 @@ func Poll(dp dataprovider.DataProvider) perm.ConnecterFunc {
 		s.RegisterRestricter("option", perm.CollectionFunc(p.readOption))
 		s.RegisterRestricter("vote", perm.CollectionFunc(p.readVote))
 
-		s.RegisterAction("poll.delete", perm.ActionFunc(p.pollDelete))
+		s.RegisterAction("poll.create", perm.ActionFunc(p.pollCreate))
+		s.RegisterAction("poll.update", perm.ActionFunc(p.pollManage))
+		s.RegisterAction("poll.start", perm.ActionFunc(p.pollStart))
+		s.RegisterAction("poll.stop", perm.ActionFunc(p.pollManage))
+		s.RegisterAction("poll.publish", perm.ActionFunc(p.pollManage))
+		s.RegisterAction("poll.anonymize", perm.ActionFunc(p.pollManage))
+		s.RegisterAction("poll.reset", perm.ActionFunc(p.pollManage))
+		s.RegisterAction("poll.delete", perm.ActionFunc(p.pollManage))
+		s.RegisterAction("poll.vote", perm.ActionFunc(p.pollVote))
 		s.RegisterAction("option.delete", perm.ActionFunc(p.optionDelete))
 		s.RegisterAction("vote.delete", perm.ActionFunc(p.voteDelete))
+
+		registerMeetingDependencies(s, dp, "poll", "state", "content_object_id")
 	}
 }
 
@@ -37,6 +47,14 @@ func (p *poll) readPoll(ctx context.Context, userID int, fqfields []perm.FQField
 		"voted_ids":    true,
 	}
 
+	if err := prefetch(ctx, p.dp, fqfields, "meeting_id", "content_object_id", "state"); err != nil {
+		return fmt.Errorf("prefetching polls: %w", err)
+	}
+
+	if err := prefetchPerms(ctx, p.dp, userID, fqfields); err != nil {
+		return fmt.Errorf("prefetching permissions: %w", err)
+	}
+
 	return p.fields(fqfields, result, restricted, func(fqfield perm.FQField) (int, error) {
 		return p.pollPerm(ctx, userID, fqfield.ID)
 	})
@@ -50,6 +68,14 @@ func (p *poll) readOption(ctx context.Context, userID int, fqfields []perm.FQFie
 		"vote_ids": true,
 	}
 
+	if err := prefetch(ctx, p.dp, fqfields, "poll_id", "used_as_global_option_in_poll_id"); err != nil {
+		return fmt.Errorf("prefetching options: %w", err)
+	}
+
+	if err := prefetchRelated(ctx, p.dp, fqfields, "poll_id", "poll", "meeting_id", "content_object_id", "state"); err != nil {
+		return fmt.Errorf("prefetching polls: %w", err)
+	}
+
 	return p.fields(fqfields, result, restricted, func(fqfield perm.FQField) (int, error) {
 		pollID, err := pollIDFromOption(ctx, p.dp, fqfield.ID)
 		if err != nil {
@@ -60,6 +86,14 @@ func (p *poll) readOption(ctx context.Context, userID int, fqfields []perm.FQFie
 }
 
 func (p *poll) readVote(ctx context.Context, userID int, fqfields []perm.FQField, result map[string]bool) error {
+	if err := prefetch(ctx, p.dp, fqfields, "option_id", "user_id", "delegated_user_id"); err != nil {
+		return fmt.Errorf("prefetching votes: %w", err)
+	}
+
+	if err := prefetchRelated(ctx, p.dp, fqfields, "option_id", "option", "poll_id", "used_as_global_option_in_poll_id"); err != nil {
+		return fmt.Errorf("prefetching options: %w", err)
+	}
+
 	return perm.AllFields(fqfields, result, func(fqfield perm.FQField) (bool, error) {
 		var optionID int
 		if err := p.dp.Get(ctx, fmt.Sprintf("vote/%d/option_id", fqfield.ID), &optionID); err != nil {
@@ -122,12 +156,15 @@ func (p *poll) fields(fqfields []perm.FQField, result map[string]bool, restricte
 	return nil
 }
 
-func (p *poll) pollDeleteWithID(ctx context.Context, userID int, pollID int) (bool, error) {
+// canManagePoll tells, if the user has the manage permission for the content
+// object of the poll.
+func (p *poll) canManagePoll(ctx context.Context, userID int, pollID int) (bool, error) {
 	fqid := "poll/" + strconv.Itoa(pollID)
 	meetingID, err := p.dp.MeetingFromModel(ctx, fqid)
 	if err != nil {
 		return false, fmt.Errorf("getting meeting id from %s: %w", fqid, err)
 	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
 
 	var contentObjectID string
 	if err := p.dp.GetIfExist(ctx, fqid+"/content_object_id", &contentObjectID); err != nil {
@@ -138,19 +175,226 @@ func (p *poll) pollDeleteWithID(ctx context.Context, userID int, pollID int) (bo
 	return perm.HasPerm(ctx, p.dp, userID, meetingID, requiredPerm)
 }
 
-func (p *poll) pollDelete(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
+// pollManage is used for all actions on an existing poll, that only need the
+// manage permission of the content object.
+func (p *poll) pollManage(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
+	var pollID int
+	if err := json.Unmarshal(payload["id"], &pollID); err != nil {
+		return false, perm.InvalidPayloadf("no id: %v", err)
+	}
+
+	return p.canManagePoll(ctx, userID, pollID)
+}
+
+func (p *poll) pollCreate(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
+	var meetingID int
+	if err := json.Unmarshal(payload["meeting_id"], &meetingID); err != nil {
+		return false, perm.InvalidPayloadf("no meeting_id: %v", err)
+	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
+
+	var pollType string
+	if err := json.Unmarshal(payload["type"], &pollType); err != nil {
+		return false, perm.InvalidPayloadf("no type: %v", err)
+	}
+
+	var contentObjectID string
+	if raw, ok := payload["content_object_id"]; ok {
+		if err := json.Unmarshal(raw, &contentObjectID); err != nil {
+			return false, perm.InvalidPayloadf("invalid content_object_id: %v", err)
+		}
+	}
+	collection := strings.Split(contentObjectID, "/")[0]
+
+	if contentObjectID != "" {
+		objectMeetingID, err := p.dp.MeetingFromModel(ctx, contentObjectID)
+		if err != nil {
+			return false, fmt.Errorf("getting meeting of %s: %w", contentObjectID, err)
+		}
+
+		if objectMeetingID != meetingID {
+			perm.LogNotAllowedf(ctx, "%s is not in meeting %d", contentObjectID, meetingID)
+			return false, nil
+		}
+	}
+
+	allowed, err := perm.HasPerm(ctx, p.dp, userID, meetingID, p.canManage(collection))
+	if err != nil || !allowed {
+		return allowed, err
+	}
+
+	if collection == "motion" {
+		allowed, err := p.motionAllowsPoll(ctx, contentObjectID)
+		if err != nil || !allowed {
+			return allowed, err
+		}
+	}
+
+	if pollType != "analog" {
+		return p.electronicVoting(ctx)
+	}
+	return true, nil
+}
+
+func (p *poll) pollStart(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
 	var pollID int
 	if err := json.Unmarshal(payload["id"], &pollID); err != nil {
-		return false, fmt.Errorf("no id in payload: %v", err)
+		return false, perm.InvalidPayloadf("no id: %v", err)
+	}
+
+	allowed, err := p.canManagePoll(ctx, userID, pollID)
+	if err != nil || !allowed {
+		return allowed, err
+	}
+
+	var pollType string
+	if err := p.dp.GetIfExist(ctx, fmt.Sprintf("poll/%d/type", pollID), &pollType); err != nil {
+		return false, fmt.Errorf("getting poll type: %w", err)
+	}
+
+	if pollType != "analog" {
+		return p.electronicVoting(ctx)
+	}
+	return true, nil
+}
+
+// pollVote tells, if the user can vote on a poll. It is used by the vote
+// service.
+//
+// The payload has the field id with the poll id and the optional field user_id
+// with the user, for whom the vote is given. If user_id is another user, this
+// user has to have delegated the vote to the requesting user. A user, that has
+// delegated the vote, can not use it anymore.
+func (p *poll) pollVote(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
+	var pollID int
+	if err := json.Unmarshal(payload["id"], &pollID); err != nil {
+		return false, perm.InvalidPayloadf("no id: %v", err)
+	}
+
+	voterID := userID
+	if raw, ok := payload["user_id"]; ok {
+		if err := json.Unmarshal(raw, &voterID); err != nil {
+			return false, perm.InvalidPayloadf("invalid user_id: %v", err)
+		}
+	}
+
+	if userID == 0 {
+		perm.LogNotAllowedf(ctx, "Anonymous can not vote")
+		return false, nil
+	}
+
+	fqid := "poll/" + strconv.Itoa(pollID)
+	meetingID, err := p.dp.MeetingFromModel(ctx, fqid)
+	if err != nil {
+		return false, fmt.Errorf("getting meeting id from %s: %w", fqid, err)
+	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
+
+	var state string
+	if err := p.dp.GetIfExist(ctx, fqid+"/state", &state); err != nil {
+		return false, fmt.Errorf("getting poll state: %w", err)
+	}
+	if state != "started" {
+		perm.LogNotAllowedf(ctx, "Poll %d is not started", pollID)
+		return false, nil
+	}
+
+	var delegatedToID int
+	if err := p.dp.GetIfExist(ctx, fmt.Sprintf("user/%d/vote_delegated_$%d_to_id", voterID, meetingID), &delegatedToID); err != nil {
+		return false, fmt.Errorf("getting vote delegation of user %d: %w", voterID, err)
+	}
+
+	if voterID == userID && delegatedToID != 0 {
+		perm.LogNotAllowedf(ctx, "User %d has delegated the vote to user %d in meeting %d", userID, delegatedToID, meetingID)
+		return false, nil
+	}
+
+	if voterID != userID && delegatedToID != userID {
+		perm.LogNotAllowedf(ctx, "User %d has not delegated the vote to user %d in meeting %d", voterID, userID, meetingID)
+		return false, nil
+	}
+
+	var presentIDs []int
+	if err := p.dp.GetIfExist(ctx, fmt.Sprintf("user/%d/is_present_in_meeting_ids", userID), &presentIDs); err != nil {
+		return false, fmt.Errorf("getting presence of user %d: %w", userID, err)
+	}
+	if !containsInt(presentIDs, meetingID) {
+		perm.LogNotAllowedf(ctx, "User %d is not present in meeting %d", userID, meetingID)
+		return false, nil
+	}
+
+	var entitledGroupIDs []int
+	if err := p.dp.GetIfExist(ctx, fqid+"/entitled_group_ids", &entitledGroupIDs); err != nil {
+		return false, fmt.Errorf("getting entitled groups: %w", err)
 	}
 
-	return p.pollDeleteWithID(ctx, userID, pollID)
+	var groupIDs []int
+	if err := p.dp.GetIfExist(ctx, fmt.Sprintf("user/%d/group_$%d_ids", voterID, meetingID), &groupIDs); err != nil {
+		return false, fmt.Errorf("getting groups of user %d: %w", voterID, err)
+	}
+
+	entitled := false
+	for _, id := range groupIDs {
+		if containsInt(entitledGroupIDs, id) {
+			entitled = true
+			break
+		}
+	}
+	if !entitled {
+		perm.LogNotAllowedf(ctx, "User %d is not in an entitled group of poll %d", voterID, pollID)
+		return false, nil
+	}
+
+	var votedIDs []int
+	if err := p.dp.GetIfExist(ctx, fqid+"/voted_ids", &votedIDs); err != nil {
+		return false, fmt.Errorf("getting voted ids: %w", err)
+	}
+	if containsInt(votedIDs, voterID) {
+		perm.LogNotAllowedf(ctx, "User %d has already voted on poll %d", voterID, pollID)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// motionAllowsPoll tells, if the state of the motion allows to create polls.
+func (p *poll) motionAllowsPoll(ctx context.Context, motionFQID string) (bool, error) {
+	var stateID int
+	if err := p.dp.Get(ctx, motionFQID+"/state_id", &stateID); err != nil {
+		return false, fmt.Errorf("getting state of %s: %w", motionFQID, err)
+	}
+
+	var allowCreatePoll bool
+	if err := p.dp.GetIfExist(ctx, fmt.Sprintf("motion_state/%d/allow_create_poll", stateID), &allowCreatePoll); err != nil {
+		return false, fmt.Errorf("getting allow_create_poll from state %d: %w", stateID, err)
+	}
+
+	if !allowCreatePoll {
+		perm.LogNotAllowedf(ctx, "The state %d of %s does not allow to create polls", stateID, motionFQID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// electronicVoting tells, if electronic voting is enabled for the
+// organisation. It is required for all polls, that are not analog.
+func (p *poll) electronicVoting(ctx context.Context) (bool, error) {
+	var enabled bool
+	if err := p.dp.GetIfExist(ctx, "organisation/1/enable_electronic_voting", &enabled); err != nil {
+		return false, fmt.Errorf("getting enable_electronic_voting: %w", err)
+	}
+
+	if !enabled {
+		perm.LogNotAllowedf(ctx, "Electronic voting is not enabled in the organisation")
+		return false, nil
+	}
+	return true, nil
 }
 
 func (p *poll) optionDelete(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
 	var optionID int
 	if err := json.Unmarshal(payload["id"], &optionID); err != nil {
-		return false, fmt.Errorf("no id in payload: %v", err)
+		return false, perm.InvalidPayloadf("no id: %v", err)
 	}
 
 	var pollID int
@@ -158,13 +402,13 @@ func (p *poll) optionDelete(ctx context.Context, userID int, payload map[string]
 		return false, fmt.Errorf("getting poll id: %w", err)
 	}
 
-	return p.pollDeleteWithID(ctx, userID, pollID)
+	return p.canManagePoll(ctx, userID, pollID)
 }
 
 func (p *poll) voteDelete(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
 	var voteID int
 	if err := json.Unmarshal(payload["id"], &voteID); err != nil {
-		return false, fmt.Errorf("no id in payload: %v", err)
+		return false, perm.InvalidPayloadf("no id: %v", err)
 	}
 
 	var optionID int
@@ -177,7 +421,7 @@ func (p *poll) voteDelete(ctx context.Context, userID int, payload map[string]js
 		return false, fmt.Errorf("getting poll id: %w", err)
 	}
 
-	return p.pollDeleteWithID(ctx, userID, pollID)
+	return p.canManagePoll(ctx, userID, pollID)
 }
 
 // pollPerm tells, if the user can see a poll.
@@ -189,6 +433,7 @@ func (p *poll) pollPerm(ctx context.Context, userID, pollID int) (int, error) {
 	if err != nil {
 		return 0, fmt.Errorf("getting meeting id: %w", err)
 	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
 
 	var contentObjectID string
 	if err := p.dp.GetIfExist(ctx, fmt.Sprintf("poll/%d/content_object_id", pollID), &contentObjectID); err != nil {
@@ -242,6 +487,7 @@ func canSeePoll(ctx context.Context, dp dataprovider.DataProvider, perms *perm.P
 		if err != nil {
 			return false, fmt.Errorf("getting meetingID from motion: %w", err)
 		}
+		ctx := perm.WithLogMeeting(ctx, meetingID)
 
 		perms, err := perm.New(ctx, dp, userID, meetingID)
 		if err != nil {
@@ -329,3 +575,12 @@ func pollIDFromOption(ctx context.Context, dp dataprovider.DataProvider, optionI
 	}
 	return pollID, nil
 }
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
+	"github.com/OpenSlides/openslides-permission-service/internal/perm"
+)
+
+// prefetch loads the given fields of all objects in fqfields with one request
+// to the datastore.
+func prefetch(ctx context.Context, dp dataprovider.DataProvider, fqfields []perm.FQField, fields ...string) error {
+	ids := uniqueIDs(fqfields)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	collection := fqfields[0].Collection
+	keys := make([]string, 0, len(ids)*len(fields))
+	for _, id := range ids {
+		for _, field := range fields {
+			keys = append(keys, fmt.Sprintf("%s/%d/%s", collection, id, field))
+		}
+	}
+	return dp.Prefetch(ctx, keys...)
+}
+
+// prefetchRelated loads the given fields of the objects, that are referenced
+// by the field idField of all objects in fqfields.
+//
+// idField has to be a prefetched field that contains an id of the collection
+// relatedCollection.
+func prefetchRelated(ctx context.Context, dp dataprovider.DataProvider, fqfields []perm.FQField, idField string, relatedCollection string, fields ...string) error {
+	ids := uniqueIDs(fqfields)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	collection := fqfields[0].Collection
+	seen := make(map[int]bool)
+	var keys []string
+	for _, id := range ids {
+		var relatedID int
+		if err := dp.GetIfExist(ctx, fmt.Sprintf("%s/%d/%s", collection, id, idField), &relatedID); err != nil {
+			return fmt.Errorf("getting %s: %w", idField, err)
+		}
+
+		if relatedID == 0 || seen[relatedID] {
+			continue
+		}
+		seen[relatedID] = true
+
+		for _, field := range fields {
+			keys = append(keys, fmt.Sprintf("%s/%d/%s", relatedCollection, relatedID, field))
+		}
+	}
+	return dp.Prefetch(ctx, keys...)
+}
+
+// prefetchPerms loads all keys to calculate the permissions of the user in the
+// meetings of the objects in fqfields.
+//
+// The field meeting_id of the objects has to be prefetched.
+func prefetchPerms(ctx context.Context, dp dataprovider.DataProvider, userID int, fqfields []perm.FQField) error {
+	ids := uniqueIDs(fqfields)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	collection := fqfields[0].Collection
+	seen := make(map[int]bool)
+	var meetingIDs []int
+	for _, id := range ids {
+		var meetingID int
+		if err := dp.GetIfExist(ctx, fmt.Sprintf("%s/%d/meeting_id", collection, id), &meetingID); err != nil {
+			return fmt.Errorf("getting meeting id: %w", err)
+		}
+
+		if meetingID == 0 || seen[meetingID] {
+			continue
+		}
+		seen[meetingID] = true
+		meetingIDs = append(meetingIDs, meetingID)
+	}
+	return perm.Prefetch(ctx, dp, userID, meetingIDs...)
+}
+
+// uniqueIDs returns all ids of the fqfields in the order of the first
+// occurrence.
+func uniqueIDs(fqfields []perm.FQField) []int {
+	seen := make(map[int]bool)
+	var ids []int
+	for _, fqfield := range fqfields {
+		if seen[fqfield.ID] {
+			continue
+		}
+		seen[fqfield.ID] = true
+		ids = append(ids, fqfield.ID)
+	}
+	return ids
+}
@@ -1,6 +1,207 @@
 package collection
 
-import "github.com/OpenSlides/openslides-permission-service/internal/perm"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
+	"github.com/OpenSlides/openslides-permission-service/internal/perm"
+)
+
+// Projector handels the permissions for projections and for projector actions,
+// that need more then one permission check. All other projector actions and
+// collections are defined in the rules.
+func Projector(dp dataprovider.DataProvider) perm.ConnecterFunc {
+	return func(s perm.HandlerStore) {
+		p := &projector{dp: dp, store: s}
+		s.RegisterRestricter("projection", perm.CollectionFunc(p.readProjection))
+
+		s.RegisterAction("projector.sort_preview", perm.ActionFunc(p.sortPreview))
+		s.RegisterAction("projector_countdown.update", perm.ActionFunc(p.countdownUpdate))
+
+		registerMeetingDependencies(s, dp, "projection", "element_id")
+	}
+}
+
+type projector struct {
+	dp    dataprovider.DataProvider
+	store perm.HandlerStore
+}
+
+// readProjection lets users with the permission projector.can_see see a
+// projection, if they can also see the projected element.
+//
+// The element is checked with the restricter of its collection. This includes
+// the restricters from the rules, for example for countdowns and messages. An
+// element of a collection without a restricter is not visible.
+//
+// The fields, that change the visibility of an element, are registered as
+// dependencies by the collection of the element. They affect all users of the
+// meeting, so the projections are also recalculated.
+func (p *projector) readProjection(ctx context.Context, userID int, fqfields []perm.FQField, result map[string]bool) error {
+	if err := prefetch(ctx, p.dp, fqfields, "meeting_id", "element_id"); err != nil {
+		return fmt.Errorf("prefetching projections: %w", err)
+	}
+
+	return perm.AllFields(fqfields, result, func(fqfield perm.FQField) (bool, error) {
+		fqid := fqfield.FQID()
+		meetingID, err := p.dp.MeetingFromModel(ctx, fqid)
+		if err != nil {
+			return false, fmt.Errorf("getting meeting id from %s: %w", fqid, err)
+		}
+		ctx := perm.WithLogMeeting(ctx, meetingID)
+
+		perms, err := perm.New(ctx, p.dp, userID, meetingID)
+		if err != nil {
+			return false, fmt.Errorf("getting perms: %w", err)
+		}
+
+		if !canSeeProjection(perms) {
+			perm.LogNotAllowedf(ctx, "User %d does not have the permission %s in meeting %d", userID, perm.ProjectorCanSee, meetingID)
+			return false, nil
+		}
+
+		var elementID string
+		if err := p.dp.GetIfExist(ctx, fqid+"/element_id", &elementID); err != nil {
+			return false, fmt.Errorf("getting element id: %w", err)
+		}
+
+		if elementID == "" {
+			return true, nil
+		}
+
+		return p.canSeeElement(ctx, userID, elementID)
+	})
+}
+
+// canSeeElement checks the element of a projection with the restricter of its
+// collection.
+func (p *projector) canSeeElement(ctx context.Context, userID int, elementID string) (bool, error) {
+	element, err := perm.ParseFQField(elementID + "/id")
+	if err != nil {
+		return false, fmt.Errorf("invalid element id `%s`: %w", elementID, err)
+	}
+
+	if element.Collection == "projection" {
+		// Do not call this restricter recursively.
+		return true, nil
+	}
+
+	restricter, ok := p.store.Restricter(element.Collection)
+	if !ok {
+		perm.LogNotAllowedf(ctx, "Unknown collection of the projected element %s", elementID)
+		return false, nil
+	}
+
+	elementResult := make(map[string]bool, 1)
+	if err := restricter.RestrictFQFields(ctx, userID, []perm.FQField{element}, elementResult); err != nil {
+		return false, fmt.Errorf("checking element %s: %w", elementID, err)
+	}
+
+	if !elementResult[element.String()] {
+		perm.LogNotAllowedf(ctx, "User %d can not see the projected element %s", userID, elementID)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *projector) sortPreview(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
+	var projectorID int
+	if err := json.Unmarshal(payload["projector_id"], &projectorID); err != nil {
+		return false, perm.InvalidPayloadf("no projector_id: %v", err)
+	}
+
+	fqid := fmt.Sprintf("projector/%d", projectorID)
+	meetingID, err := p.dp.MeetingFromModel(ctx, fqid)
+	if err != nil {
+		return false, fmt.Errorf("getting meeting id from %s: %w", fqid, err)
+	}
+
+	return perm.HasPerm(ctx, p.dp, userID, meetingID, perm.ProjectorCanManage)
+}
+
+// countdownUpdate handles projector_countdown.update.
+//
+// The backend starts, stops and resets a countdown with an update of the fields
+// running and countdown_time. If the countdown is coupled with the list of
+// speakers, managers of the list of speakers can also do this.
+func (p *projector) countdownUpdate(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
+	var countdownID int
+	if err := json.Unmarshal(payload["id"], &countdownID); err != nil {
+		return false, perm.InvalidPayloadf("no id: %v", err)
+	}
+
+	fqid := fmt.Sprintf("projector_countdown/%d", countdownID)
+	meetingID, err := p.dp.MeetingFromModel(ctx, fqid)
+	if err != nil {
+		return false, fmt.Errorf("getting meeting id from %s: %w", fqid, err)
+	}
+	ctx = perm.WithLogMeeting(ctx, meetingID)
+
+	perms, err := perm.New(ctx, p.dp, userID, meetingID)
+	if err != nil {
+		return false, fmt.Errorf("getting perms: %w", err)
+	}
+
+	if perms.Has(perm.ProjectorCanManage) {
+		return true, nil
+	}
+
+	if !perms.Has(perm.ListOfSpeakersCanManage) {
+		perm.LogNotAllowedf(ctx, "User %d does not have the permission %s in meeting %d", userID, perm.ProjectorCanManage, meetingID)
+		return false, nil
+	}
+
+	for k := range payload {
+		switch k {
+		case "id", "running", "countdown_time":
+		default:
+			perm.LogNotAllowedf(ctx, "Managers of the list of speakers can not modify the field %s of a countdown", k)
+			return false, nil
+		}
+	}
+
+	coupled, err := p.isCoupledCountdown(ctx, meetingID, countdownID)
+	if err != nil {
+		return false, fmt.Errorf("checking coupled countdown: %w", err)
+	}
+
+	if !coupled {
+		perm.LogNotAllowedf(ctx, "Countdown %d is not coupled with the list of speakers", countdownID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// isCoupledCountdown tells, if the countdown is coupled with the list of
+// speakers.
+//
+// If list_of_speakers_couple_countdown is enabled, the list of speakers uses
+// the first countdown of the meeting. That is the countdown with the lowest id.
+func (p *projector) isCoupledCountdown(ctx context.Context, meetingID, countdownID int) (bool, error) {
+	var coupled bool
+	if err := p.dp.GetIfExist(ctx, fmt.Sprintf("meeting/%d/list_of_speakers_couple_countdown", meetingID), &coupled); err != nil {
+		return false, fmt.Errorf("getting list_of_speakers_couple_countdown: %w", err)
+	}
+
+	if !coupled {
+		return false, nil
+	}
+
+	var countdownIDs []int
+	if err := p.dp.GetIfExist(ctx, fmt.Sprintf("meeting/%d/projector_countdown_ids", meetingID), &countdownIDs); err != nil {
+		return false, fmt.Errorf("getting projector_countdown_ids: %w", err)
+	}
+
+	firstID := 0
+	for _, id := range countdownIDs {
+		if firstID == 0 || id < firstID {
+			firstID = id
+		}
+	}
+	return firstID == countdownID, nil
+}
 
 func canSeeProjection(p *perm.Permission) bool {
 	return p.Has(perm.ProjectorCanSee)
@@ -85,15 +85,29 @@ func hasPerm(dp dataprovider.DataProvider, permission perm.TPermission, collecti
 	}
 }
 
+// WriteRule is the permission, that is needed for an action.
+//
+// Collection is the collection of the object in the payload field `id`. If it
+// is empty, the first part of the action name is used.
+type WriteRule struct {
+	Permission perm.TPermission
+	Collection string
+}
+
 // WritePerm initializes actions, that only need one permission
-func WritePerm(dp dataprovider.DataProvider, def map[string]perm.TPermission) perm.ConnecterFunc {
+func WritePerm(dp dataprovider.DataProvider, def map[string]WriteRule) perm.ConnecterFunc {
 	return func(s perm.HandlerStore) {
-		for route, perm := range def {
+		for route, rule := range def {
 			parts := strings.Split(route, ".")
 			if len(parts) != 2 {
 				panic("Invalid WritePerm action: " + route)
 			}
-			s.RegisterAction(route, (writeChecker(dp, parts[0], perm)))
+
+			collName := rule.Collection
+			if collName == "" {
+				collName = parts[0]
+			}
+			s.RegisterAction(route, (writeChecker(dp, collName, rule.Permission)))
 		}
 	}
 }
@@ -104,7 +118,7 @@ func writeChecker(dp dataprovider.DataProvider, collName string, permission perm
 		if err := json.Unmarshal(payload["meeting_id"], &meetingID); err != nil {
 			var id int
 			if err := json.Unmarshal(payload["id"], &id); err != nil {
-				return false, fmt.Errorf("invalid payload. Action needs payload `meeting_id`<int> or `id`<int>")
+				return false, perm.InvalidPayloadf("action needs payload `meeting_id`<int> or `id`<int>")
 			}
 
 			fqid := collName + "/" + strconv.Itoa(id)
@@ -137,6 +151,8 @@ func OrgaManager(dp dataprovider.DataProvider, actions ...string) perm.Connecter
 					if orgaLevel == "can_manage_organisation" {
 						return true, nil
 					}
+
+					perm.LogNotAllowedf(ctx, "User %d is not an organisation manager", userID)
 					return false, nil
 				},
 			))
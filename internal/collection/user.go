@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
-	"strings"
 
 	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
 	"github.com/OpenSlides/openslides-permission-service/internal/perm"
@@ -27,6 +26,15 @@ func User(dp dataprovider.DataProvider) perm.ConnecterFunc {
 		s.RegisterAction("user.set_present", perm.ActionFunc(u.setPresent))
 
 		s.RegisterRestricter("user", perm.CollectionFunc(u.read))
+
+		s.RegisterDependency("user", "group_$", userGroupUsers(dp))
+		for _, field := range []string{
+			"organisation_management_level",
+			"committee_as_member_ids",
+			"committee_as_manager_ids",
+		} {
+			s.RegisterDependency("user", field, changedUser)
+		}
 	}
 }
 
@@ -105,7 +113,7 @@ func (u *user) passwordSelf(ctx context.Context, userID int, payload map[string]
 func (u *user) setPresent(ctx context.Context, userID int, payload map[string]json.RawMessage) (bool, error) {
 	var meetingID int
 	if err := json.Unmarshal(payload["meeting_id"], &meetingID); err != nil {
-		return false, fmt.Errorf("decoding meeting_id from payload: %w", err)
+		return false, perm.InvalidPayloadf("decoding meeting_id: %v", err)
 	}
 
 	var allowSetPresent bool
@@ -150,6 +158,10 @@ func (u *user) read(ctx context.Context, userID int, fqfields []perm.FQField, re
 
 	meetingFields := make(map[int]map[string]bool)
 
+	if err := u.prefetch(ctx, userID, fqfields); err != nil {
+		return fmt.Errorf("prefetching users: %w", err)
+	}
+
 	grouped := groupByID(fqfields)
 	for _, fqfields := range grouped {
 		seeFields := make(map[string]bool)
@@ -212,12 +224,12 @@ func (u *user) read(ctx context.Context, userID int, fqfields []perm.FQField, re
 		}
 
 		for _, f := range fqfields {
-			if !seeFields[templateFieldPrefix(f)] {
+			if !seeFields[f.TemplatePrefix()] {
 				continue
 			}
 
 			if mid := meetingFilter(f); mid != 0 {
-				if !meetingFields[mid][templateFieldPrefix(f)] {
+				if !meetingFields[mid][f.TemplatePrefix()] {
 					continue
 				}
 			}
@@ -227,9 +239,42 @@ func (u *user) read(ctx context.Context, userID int, fqfields []perm.FQField, re
 	return nil
 }
 
+// prefetch loads the meetings of all requested users and the permissions of
+// the request user in this meetings.
+func (u *user) prefetch(ctx context.Context, userID int, fqfields []perm.FQField) error {
+	if err := prefetch(ctx, u.dp, fqfields, "group_$_ids"); err != nil {
+		return fmt.Errorf("prefetching meeting ids: %w", err)
+	}
+
+	seen := make(map[int]bool)
+	var meetingIDs []int
+	for _, id := range uniqueIDs(fqfields) {
+		var meetingIDsStr []string
+		if err := u.dp.GetIfExist(ctx, fmt.Sprintf("user/%d/group_$_ids", id), &meetingIDsStr); err != nil {
+			return fmt.Errorf("getting meeting ids: %w", err)
+		}
+
+		for _, midS := range meetingIDsStr {
+			mid, err := strconv.Atoi(midS)
+			if err != nil {
+				return fmt.Errorf("invalid meetingid: %s", midS)
+			}
+
+			if seen[mid] {
+				continue
+			}
+			seen[mid] = true
+			meetingIDs = append(meetingIDs, mid)
+		}
+	}
+
+	return perm.Prefetch(ctx, u.dp, userID, meetingIDs...)
+}
+
 func isRequired(ctx context.Context, dp dataprovider.DataProvider, userID int, otherUserID int, meetingIDs []int) (bool, error) {
 	var ids []int
 	for _, mid := range meetingIDs {
+		ctx := perm.WithLogMeeting(ctx, mid)
 		p, err := perm.New(ctx, dp, userID, mid)
 		if err != nil {
 			return false, fmt.Errorf("getting perms: %w", err)
@@ -361,18 +406,10 @@ func addMap(data map[string]bool, m map[string]bool) {
 	}
 }
 
-func templateFieldPrefix(fqfield perm.FQField) string {
-	i := strings.IndexByte(fqfield.Field, '$')
-	if i < 0 {
-		return fqfield.Field
-	}
-	return fqfield.Field[:i+1]
-}
-
 // meetingFilter acts on speciel fields containing a meeting id. For this
 // fields, it returns the meeting id. for other fields, it returns 0.
 func meetingFilter(fqfield perm.FQField) int {
-	p := templateFieldPrefix(fqfield)
+	p := fqfield.TemplatePrefix()
 	switch p {
 	case "number_$", "structure_level_$", "about_me_$", "vote_weight_$":
 		if len(p) == len(fqfield.Field) {
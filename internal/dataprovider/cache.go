@@ -0,0 +1,79 @@
+package dataprovider
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+type cacheKey struct{}
+
+// cache holds the values of the datastore for the lifetime of one request.
+//
+// A key that exists in data with the value nil does not exist in the
+// datastore.
+type cache struct {
+	mu   sync.Mutex
+	data map[string]json.RawMessage
+}
+
+// WithCache returns a context that holds a cache for datastore values.
+//
+// All calls to a DataProvider with this context (or a child context) only
+// request each key once from the external data provider. This also includes
+// keys, that do not exist.
+//
+// The cache lives as long as the context is used. It should only be used for
+// one request, since it is never invalidated.
+//
+// If the given context already has a cache, it is returned unchanged.
+func WithCache(ctx context.Context) context.Context {
+	if cacheFromContext(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, cacheKey{}, &cache{data: make(map[string]json.RawMessage)})
+}
+
+func cacheFromContext(ctx context.Context) *cache {
+	c, _ := ctx.Value(cacheKey{}).(*cache)
+	return c
+}
+
+// get returns the values for the given keys.
+//
+// Only keys that are not in the cache are requested with the function fetch.
+// The function is not called, if all keys are in the cache.
+func (c *cache) get(keys []string, fetch func(keys []string) ([]json.RawMessage, error)) ([]json.RawMessage, error) {
+	values := make([]json.RawMessage, len(keys))
+	var missing []string
+	var missingIdx []int
+
+	c.mu.Lock()
+	for i, key := range keys {
+		v, ok := c.data[key]
+		if !ok {
+			missing = append(missing, key)
+			missingIdx = append(missingIdx, i)
+			continue
+		}
+		values[i] = v
+	}
+	c.mu.Unlock()
+
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	fetched, err := fetch(missing)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, key := range missing {
+		c.data[key] = fetched[i]
+		values[missingIdx[i]] = fetched[i]
+	}
+	return values, nil
+}
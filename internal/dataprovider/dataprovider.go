@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 type externalDataProvider interface {
@@ -19,14 +20,55 @@ type DataProvider struct {
 	External externalDataProvider
 }
 
+// externalGet returns the values from the external data provider.
+//
+// If the context has a cache (see WithCache), each key is only requested once.
 func (dp *DataProvider) externalGet(ctx context.Context, fields ...string) ([]json.RawMessage, error) {
-	return dp.External.Get(ctx, fields...)
+	get := func(keys []string) ([]json.RawMessage, error) {
+		values, err := dp.External.Get(ctx, keys...)
+		if err != nil {
+			return nil, ExternalError{err}
+		}
+
+		if len(values) != len(keys) {
+			return nil, ExternalError{fmt.Errorf("requested %d keys, got %d values", len(keys), len(values))}
+		}
+		return values, nil
+	}
+
+	c := cacheFromContext(ctx)
+	if c == nil {
+		return get(fields)
+	}
+	return c.get(fields, get)
+}
+
+// Prefetch requests the given keys with one request from the external data
+// provider and saves them in the cache of the context.
+//
+// Later calls to Get() or GetIfExist() for this keys do not need a request to
+// the datastore. Keys, that are already in the cache are not requested again.
+//
+// If the context does not have a cache (see WithCache), Prefetch does nothing.
+func (dp *DataProvider) Prefetch(ctx context.Context, keys ...string) error {
+	if cacheFromContext(ctx) == nil || len(keys) == 0 {
+		return nil
+	}
+
+	if _, err := dp.externalGet(ctx, keys...); err != nil {
+		return fmt.Errorf("prefetching %d keys: %w", len(keys), err)
+	}
+	return nil
 }
 
 // Get returns a value from the datastore and unpacks it in to the argument value.
 //
 // The argument value has to be an non nil pointer.
 func (dp *DataProvider) Get(ctx context.Context, fqfield string, value interface{}) error {
+	if !validKey(fqfield) {
+		return InvalidKeyError(fqfield)
+	}
+
 	fields, err := dp.externalGet(ctx, fqfield)
 	if err != nil {
 		return fmt.Errorf("getting data from datastore: %w", err)
@@ -109,3 +151,15 @@ func (dp *DataProvider) MeetingFromModel(ctx context.Context, fqid string) (int,
 	}
 	return id, nil
 }
+
+// validKey returns true, if the key has the form collection/id/field with a
+// positive id.
+func validKey(key string) bool {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return false
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	return err == nil && id > 0
+}
@@ -0,0 +1,93 @@
+package dataprovider_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
+)
+
+type countingDataProvider struct {
+	data  map[string]json.RawMessage
+	count map[string]int
+}
+
+func (c *countingDataProvider) Get(ctx context.Context, keys ...string) ([]json.RawMessage, error) {
+	values := make([]json.RawMessage, len(keys))
+	for i, key := range keys {
+		c.count[key]++
+		values[i] = c.data[key]
+	}
+	return values, nil
+}
+
+func TestCache(t *testing.T) {
+	external := &countingDataProvider{
+		data:  map[string]json.RawMessage{"user/1/username": []byte(`"hugo"`)},
+		count: make(map[string]int),
+	}
+	dp := dataprovider.DataProvider{External: external}
+	ctx := dataprovider.WithCache(context.Background())
+
+	for i := 0; i < 3; i++ {
+		var username string
+		if err := dp.Get(ctx, "user/1/username", &username); err != nil {
+			t.Fatalf("Get returned unexpected error: %v", err)
+		}
+		if username != "hugo" {
+			t.Errorf("Got username %s, expected hugo", username)
+		}
+
+		var unknown string
+		if err := dp.GetIfExist(ctx, "user/1/unknown", &unknown); err != nil {
+			t.Fatalf("GetIfExist returned unexpected error: %v", err)
+		}
+	}
+
+	for _, key := range []string{"user/1/username", "user/1/unknown"} {
+		if got := external.count[key]; got != 1 {
+			t.Errorf("Key %s was requested %d times, expected 1", key, got)
+		}
+	}
+}
+
+func TestWithoutCache(t *testing.T) {
+	external := &countingDataProvider{
+		data:  map[string]json.RawMessage{"user/1/username": []byte(`"hugo"`)},
+		count: make(map[string]int),
+	}
+	dp := dataprovider.DataProvider{External: external}
+
+	for i := 0; i < 2; i++ {
+		var username string
+		if err := dp.Get(context.Background(), "user/1/username", &username); err != nil {
+			t.Fatalf("Get returned unexpected error: %v", err)
+		}
+	}
+
+	if got := external.count["user/1/username"]; got != 2 {
+		t.Errorf("Key was requested %d times, expected 2", got)
+	}
+}
+
+type shortDataProvider struct{}
+
+func (shortDataProvider) Get(ctx context.Context, keys ...string) ([]json.RawMessage, error) {
+	return nil, nil
+}
+
+func TestWrongNumberOfValues(t *testing.T) {
+	dp := dataprovider.DataProvider{External: shortDataProvider{}}
+
+	for _, ctx := range []context.Context{context.Background(), dataprovider.WithCache(context.Background())} {
+		var username string
+		err := dp.Get(ctx, "user/1/username", &username)
+
+		var errExternal dataprovider.ExternalError
+		if !errors.As(err, &errExternal) {
+			t.Errorf("Got error `%v`, expected an ExternalError", err)
+		}
+	}
+}
@@ -2,6 +2,30 @@ package dataprovider
 
 import "fmt"
 
+// ExternalError is returned, when the external data provider returned an
+// error.
+type ExternalError struct {
+	err error
+}
+
+func (e ExternalError) Error() string {
+	return fmt.Sprintf("external data provider: %v", e.err)
+}
+
+// Unwrap returns the error from the external data provider.
+func (e ExternalError) Unwrap() error {
+	return e.err
+}
+
+// InvalidKeyError is returned, when a requested key is not a valid fqfield.
+//
+// This happens, when an id in a payload is missing or has a wrong format.
+type InvalidKeyError string
+
+func (e InvalidKeyError) Error() string {
+	return fmt.Sprintf("%s is not a valid key.", string(e))
+}
+
 // DoesNotExistError is thowen when an field does not exist.
 type DoesNotExistError string
 
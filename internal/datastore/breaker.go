@@ -0,0 +1,85 @@
+package datastore
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, when the datastore reader failed to often and
+// requests are not send anymore.
+var ErrCircuitOpen = errors.New("datastore reader is not available")
+
+// circuitBreaker counts the failed requests. If there are to many failures in
+// a row, it stops all requests for a cooldown duration.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow returns ErrCircuitOpen, if no request should be sent.
+//
+// After the cooldown, only one request is allowed until success() or
+// failure() is called.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+
+	if b.now().Before(b.openUntil) || b.probing {
+		return ErrCircuitOpen
+	}
+
+	b.probing = true
+	return nil
+}
+
+// success resets the breaker.
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// release allows the next request after the cooldown without changing the
+// counted failures.
+//
+// It has to be called, when a request ended and it is unknown, if the
+// datastore reader is available.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// failure counts a failed request.
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
@@ -0,0 +1,162 @@
+package datastore
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type getter interface {
+	Get(ctx context.Context, keys ...string) ([]json.RawMessage, error)
+}
+
+// Cache saves values from a datastore for all requests.
+//
+// It implements the permission.DataProvider interface. It has to be created
+// with NewCache.
+//
+// The cache holds at most size keys. If there are more keys, the key that was
+// not used for the longest time is removed. Each key is removed after the ttl.
+// Keys that changed in the datastore have to be removed with Invalidate.
+type Cache struct {
+	backend getter
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List
+	generation uint64
+}
+
+type cacheEntry struct {
+	key     string
+	value   json.RawMessage
+	expires time.Time
+}
+
+// NewCache creates a Cache in front of the given backend.
+func NewCache(backend getter, size int, ttl time.Duration) *Cache {
+	return &Cache{
+		backend: backend,
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Get returns the values for the given keys.
+//
+// Only the keys that are not in the cache are requested from the backend.
+func (c *Cache) Get(ctx context.Context, keys ...string) ([]json.RawMessage, error) {
+	values := make([]json.RawMessage, len(keys))
+	var missing []string
+	var missingIdx []int
+
+	c.mu.Lock()
+	now := c.now()
+	for i, key := range keys {
+		value, ok := c.lookup(key, now)
+		if !ok {
+			missing = append(missing, key)
+			missingIdx = append(missingIdx, i)
+			continue
+		}
+		values[i] = value
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	cacheKeys.Add(float64(len(keys)-len(missing)), "hit")
+	cacheKeys.Add(float64(len(missing)), "miss")
+
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	fetched, err := c.backend.Get(ctx, missing...)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %d keys from backend: %w", len(missing), err)
+	}
+
+	if len(fetched) != len(missing) {
+		return nil, fmt.Errorf("backend returned %d values for %d keys", len(fetched), len(missing))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// If keys were invalidated while fetching, the fetched values could be
+	// outdated. In this case, they are returned but not saved.
+	store := generation == c.generation
+	for i, key := range missing {
+		values[missingIdx[i]] = fetched[i]
+		if store {
+			c.add(key, fetched[i], now)
+		}
+	}
+	return values, nil
+}
+
+// Invalidate removes the given keys from the cache.
+func (c *Cache) Invalidate(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, key := range keys {
+		if e, ok := c.entries[key]; ok {
+			c.lru.Remove(e)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// lookup returns the value of a key. The second return value is false, if the
+// key is not in the cache or expired.
+//
+// Has to be called with locked mutex.
+func (c *Cache) lookup(key string, now time.Time) (json.RawMessage, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*cacheEntry)
+	if !now.Before(entry.expires) {
+		c.lru.Remove(e)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(e)
+	return entry.value, true
+}
+
+// add saves a value in the cache and removes the oldest entries, if the cache
+// is full.
+//
+// Has to be called with locked mutex.
+func (c *Cache) add(key string, value json.RawMessage, now time.Time) {
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		entry.value = value
+		entry.expires = now.Add(c.ttl)
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value, expires: now.Add(c.ttl)})
+
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
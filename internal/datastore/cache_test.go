@@ -0,0 +1,116 @@
+package datastore_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/datastore"
+)
+
+type countingBackend struct {
+	data  map[string]json.RawMessage
+	count map[string]int
+}
+
+func newCountingBackend(data map[string]json.RawMessage) *countingBackend {
+	return &countingBackend{data: data, count: make(map[string]int)}
+}
+
+func (b *countingBackend) Get(ctx context.Context, keys ...string) ([]json.RawMessage, error) {
+	values := make([]json.RawMessage, len(keys))
+	for i, key := range keys {
+		b.count[key]++
+		values[i] = b.data[key]
+	}
+	return values, nil
+}
+
+func TestCache(t *testing.T) {
+	backend := newCountingBackend(map[string]json.RawMessage{"group/1/permissions": []byte(`["motion.can_see"]`)})
+	cache := datastore.NewCache(backend, 10, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		values, err := cache.Get(context.Background(), "group/1/permissions", "group/2/permissions")
+		if err != nil {
+			t.Fatalf("Get returned unexpected error: %v", err)
+		}
+
+		if string(values[0]) != `["motion.can_see"]` || values[1] != nil {
+			t.Errorf("Got values %q, expected the values of the backend", values)
+		}
+	}
+
+	for _, key := range []string{"group/1/permissions", "group/2/permissions"} {
+		if got := backend.count[key]; got != 1 {
+			t.Errorf("Key %s was requested %d times, expected 1", key, got)
+		}
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	backend := newCountingBackend(map[string]json.RawMessage{"group/1/permissions": []byte(`["motion.can_see"]`)})
+	cache := datastore.NewCache(backend, 10, time.Hour)
+
+	if _, err := cache.Get(context.Background(), "group/1/permissions"); err != nil {
+		t.Fatalf("Get returned unexpected error: %v", err)
+	}
+
+	backend.data["group/1/permissions"] = []byte(`["motion.can_manage"]`)
+	cache.Invalidate("group/1/permissions")
+
+	values, err := cache.Get(context.Background(), "group/1/permissions")
+	if err != nil {
+		t.Fatalf("Get returned unexpected error: %v", err)
+	}
+
+	if string(values[0]) != `["motion.can_manage"]` {
+		t.Errorf("Got value %s, expected the new value", values[0])
+	}
+}
+
+func TestCacheSize(t *testing.T) {
+	backend := newCountingBackend(nil)
+	cache := datastore.NewCache(backend, 2, time.Hour)
+
+	for _, key := range []string{"user/1/id", "user/2/id", "user/3/id", "user/1/id"} {
+		if _, err := cache.Get(context.Background(), key); err != nil {
+			t.Fatalf("Get returned unexpected error: %v", err)
+		}
+	}
+
+	if got := backend.count["user/1/id"]; got != 2 {
+		t.Errorf("Oldest key was requested %d times, expected 2", got)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	backend := newCountingBackend(nil)
+	cache := datastore.NewCache(backend, 10, time.Nanosecond)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Get(context.Background(), "user/1/id"); err != nil {
+			t.Fatalf("Get returned unexpected error: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := backend.count["user/1/id"]; got != 2 {
+		t.Errorf("Key was requested %d times, expected 2", got)
+	}
+}
+
+type shortBackend struct{}
+
+func (shortBackend) Get(ctx context.Context, keys ...string) ([]json.RawMessage, error) {
+	return []json.RawMessage{[]byte("1")}, nil
+}
+
+func TestCacheWrongNumberOfValues(t *testing.T) {
+	cache := datastore.NewCache(shortBackend{}, 10, time.Hour)
+
+	if _, err := cache.Get(context.Background(), "user/1/id", "user/2/id"); err == nil {
+		t.Errorf("Get returned no error, expected one for the short backend response")
+	}
+}
@@ -3,32 +3,124 @@
 // On the one end, it sends http requests to the datastore-service, on the other
 // end, it impelements the permission.ExternalDataProvider interface.
 //
-// At least for now, there is no caching.
+// The Cache can be used in front of the Datastore to save values between
+// requests.
 package datastore
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
 const urlPath = "/internal/datastore/reader/get_many"
 
 // Datastore connects to the datastore service. It implements the
 // permission.ExternalDataProvider interface.
+//
+// The zero value (with an address) can be used and uses http.DefaultClient
+// without timeouts and retries. Use New() for a configured object.
 type Datastore struct {
 	Addr string
+
+	client       *http.Client
+	maxIdleConns int
+	timeout      time.Duration
+	maxRetries   int
+	retryBackoff time.Duration
+	breaker      *circuitBreaker
+}
+
+// New creates a Datastore object for the given address.
+func New(addr string, options ...Option) *Datastore {
+	db := &Datastore{Addr: addr}
+	for _, o := range options {
+		o(db)
+	}
+
+	if db.client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if db.maxIdleConns > 0 {
+			transport.MaxIdleConns = db.maxIdleConns
+			transport.MaxIdleConnsPerHost = db.maxIdleConns
+		}
+		db.client = &http.Client{Transport: transport}
+	}
+	return db
+}
+
+// Option is an optional argument for New().
+type Option func(*Datastore)
+
+// WithClient sets the http client that is used for the requests.
+//
+// If this option is used, WithMaxIdleConns has no effect.
+func WithClient(client *http.Client) Option {
+	return func(db *Datastore) {
+		db.client = client
+	}
+}
+
+// WithMaxIdleConns sets the number of connections that are kept open to the
+// datastore reader.
+func WithMaxIdleConns(n int) Option {
+	return func(db *Datastore) {
+		db.maxIdleConns = n
+	}
+}
+
+// WithTimeout sets the maximum duration of one request to the datastore
+// reader. Each retry has its own timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(db *Datastore) {
+		db.timeout = timeout
+	}
+}
+
+// WithRetries sets the number of retries, when the datastore reader is not
+// reachable or returns a status code 5xx.
+//
+// Before each retry, the Datastore waits for backoff. The backoff is doubled
+// after each retry.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(db *Datastore) {
+		db.maxRetries = maxRetries
+		db.retryBackoff = backoff
+	}
+}
+
+// WithCircuitBreaker lets requests fail immediately, when the last threshold
+// requests failed. A request counts as failed, if it and all of its retries
+// failed.
+//
+// After cooldown, one request is tried again. If it succeeds, all requests are
+// send again.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(db *Datastore) {
+		if threshold <= 0 {
+			db.breaker = nil
+			return
+		}
+		db.breaker = newCircuitBreaker(threshold, cooldown)
+	}
 }
 
 // Get fetches a list of fqfields from the datastore.
 func (db *Datastore) Get(ctx context.Context, fqfields ...string) ([]json.RawMessage, error) {
+	start := time.Now()
 	keyValues, err := db.requestKeys(ctx, fqfields)
+	getManyDuration.Since(start)
+	getManyKeys.Add(float64(len(fqfields)))
 	if err != nil {
+		getManyRequests.Inc("error")
 		return nil, fmt.Errorf("request keys: %w", err)
 	}
+	getManyRequests.Inc("ok")
 
 	values := make([]json.RawMessage, len(fqfields))
 	for i, key := range fqfields {
@@ -65,6 +157,57 @@ func (db *Datastore) requestKeys(ctx context.Context, keys []string) (map[string
 		return nil, fmt.Errorf("creating GetManyRequest: %w", err)
 	}
 
+	if db.breaker != nil {
+		if err := db.breaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	backoff := db.retryBackoff
+	for retry := 0; ; retry++ {
+		responseData, err := db.requestOnce(ctx, requestData)
+		if err == nil {
+			if db.breaker != nil {
+				db.breaker.success()
+			}
+			return responseData, nil
+		}
+
+		var rErr retryError
+		retryable := errors.As(err, &rErr) && ctx.Err() == nil
+		if !retryable || retry >= db.maxRetries {
+			if db.breaker != nil {
+				if retryable {
+					db.breaker.failure()
+				} else {
+					db.breaker.release()
+				}
+			}
+			return nil, fmt.Errorf("requesting keys `%v`: %w", keys, err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			if db.breaker != nil {
+				db.breaker.release()
+			}
+			return nil, fmt.Errorf("requesting keys `%v`: %w", keys, ctx.Err())
+		}
+		backoff *= 2
+	}
+}
+
+// requestOnce sends one get_many request to the datastore reader.
+//
+// Errors, that should lead to a retry are wrapped in a retryError.
+func (db *Datastore) requestOnce(ctx context.Context, requestData []byte) (map[string]json.RawMessage, error) {
+	if db.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, db.timeout)
+		defer cancel()
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "POST", db.url(), bytes.NewReader(requestData))
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -72,18 +215,30 @@ func (db *Datastore) requestKeys(ctx context.Context, keys []string) (map[string
 
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	client := db.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("requesting keys `%v`: %w", keys, err)
+		return nil, retryError{err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("datastore returned status %s", resp.Status)
+		var err error
+		body, readErr := io.ReadAll(resp.Body)
+		if readErr != nil {
+			err = fmt.Errorf("datastore returned status %s", resp.Status)
+		} else {
+			err = fmt.Errorf("datastore returned status %s: %s", resp.Status, body)
+		}
+
+		if resp.StatusCode >= 500 {
+			return nil, retryError{err}
 		}
-		return nil, fmt.Errorf("datastore returned status %s: %s", resp.Status, body)
+		return nil, err
 	}
 
 	responseData, err := getManyResponceToKeyValue(resp.Body)
@@ -94,6 +249,19 @@ func (db *Datastore) requestKeys(ctx context.Context, keys []string) (map[string
 	return responseData, nil
 }
 
+// retryError is an error of a request that can be retried.
+type retryError struct {
+	err error
+}
+
+func (e retryError) Error() string {
+	return e.err.Error()
+}
+
+func (e retryError) Unwrap() error {
+	return e.err
+}
+
 // keysToGetManyRequest a json encoding of the get_many request.
 func keysToGetManyRequest(keys []string) (json.RawMessage, error) {
 	request := struct {
@@ -3,7 +3,11 @@ package datastore_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/OpenSlides/openslides-permission-service/internal/datastore"
 	"github.com/OpenSlides/openslides-permission-service/internal/tests"
@@ -56,3 +60,99 @@ func TestDataStoreNull(t *testing.T) {
 		t.Errorf("Got first value `%s`, expected nil", result[0])
 	}
 }
+
+func TestDatastoreTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	db := datastore.New(ts.URL, datastore.WithTimeout(10*time.Millisecond))
+
+	start := time.Now()
+	if _, err := db.Get(context.Background(), "collection/1/name"); err == nil {
+		t.Fatalf("Got no error, expected a timeout")
+	}
+
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("Get took %v, expected to stop after the timeout", d)
+	}
+}
+
+func TestDatastoreRetry(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"collection":{"1":{"name":"value"}}}`))
+	}))
+	defer ts.Close()
+
+	db := datastore.New(ts.URL, datastore.WithRetries(2, time.Millisecond))
+
+	result, err := db.Get(context.Background(), "collection/1/name")
+	if err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	}
+
+	if string(result[0]) != `"value"` {
+		t.Errorf("Got value `%s`, expected `\"value\"`", result[0])
+	}
+
+	if calls != 3 {
+		t.Errorf("Datastore was called %d times, expected 3", calls)
+	}
+}
+
+func TestDatastoreNoRetryOnClientError(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "invalid key", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	db := datastore.New(ts.URL, datastore.WithRetries(2, time.Millisecond))
+
+	if _, err := db.Get(context.Background(), "collection/1/name"); err == nil {
+		t.Fatalf("Got no error, expected one")
+	}
+
+	if calls != 1 {
+		t.Errorf("Datastore was called %d times, expected 1", calls)
+	}
+}
+
+func TestDatastoreCircuitBreaker(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "broken", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	db := datastore.New(ts.URL, datastore.WithCircuitBreaker(2, time.Hour))
+
+	for i := 0; i < 2; i++ {
+		if _, err := db.Get(context.Background(), "collection/1/name"); err == nil {
+			t.Fatalf("Got no error, expected one")
+		}
+	}
+
+	_, err := db.Get(context.Background(), "collection/1/name")
+	if !errors.Is(err, datastore.ErrCircuitOpen) {
+		t.Errorf("Got error `%v`, expected ErrCircuitOpen", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("Datastore was called %d times, expected 2", calls)
+	}
+}
@@ -0,0 +1,162 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dump is an in memory datastore. It maps keys to there values.
+//
+// It implements the permission.DataProvider interface and can be used to
+// evaluate permissions without a datastore service.
+type Dump map[string]json.RawMessage
+
+// LoadDump reads a datastore dump in yaml or json format.
+//
+// See ParseDump for the format.
+func LoadDump(r io.Reader) (Dump, error) {
+	var db map[string]interface{}
+	if err := yaml.NewDecoder(r).Decode(&db); err != nil {
+		return nil, fmt.Errorf("decoding dump: %w", err)
+	}
+
+	return ParseDump(db)
+}
+
+// ParseDump creates a Dump from decoded yaml or json data.
+//
+// The keys of the data can be collections, fqids or fqfields:
+//
+//	motion:
+//	  1:
+//	    title: first motion
+//	motion/2:
+//	  title: second motion
+//	motion/3/title: third motion
+//
+// For each object, the field `id` is set.
+func ParseDump(db map[string]interface{}) (Dump, error) {
+	data := make(Dump)
+	for dbKey, dbValue := range db {
+		parts := strings.Split(dbKey, "/")
+		switch len(parts) {
+		case 1:
+			objects, err := stringMap(dbValue)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for collection %s: %w", dbKey, err)
+			}
+
+			for rawID, rawObject := range objects {
+				if err := data.addObject(dbKey, rawID, rawObject); err != nil {
+					return nil, fmt.Errorf("adding object %s/%s: %w", dbKey, rawID, err)
+				}
+			}
+
+		case 2:
+			if err := data.addObject(parts[0], parts[1], dbValue); err != nil {
+				return nil, fmt.Errorf("adding object %s: %w", dbKey, err)
+			}
+
+		case 3:
+			if _, err := strconv.Atoi(parts[1]); err != nil {
+				return nil, fmt.Errorf("invalid id in key %s", dbKey)
+			}
+
+			if err := data.addValue(dbKey, dbValue); err != nil {
+				return nil, err
+			}
+			data[parts[0]+"/"+parts[1]+"/id"] = []byte(parts[1])
+
+		default:
+			return nil, fmt.Errorf("invalid db key %s", dbKey)
+		}
+	}
+	return data, nil
+}
+
+// Get implements the permission.DataProvider interface.
+func (d Dump) Get(ctx context.Context, keys ...string) ([]json.RawMessage, error) {
+	values := make([]json.RawMessage, len(keys))
+	for i, key := range keys {
+		values[i] = d[key]
+	}
+	return values, nil
+}
+
+// addObject adds all fields of one object.
+func (d Dump) addObject(collection string, rawID string, rawObject interface{}) error {
+	if _, err := strconv.Atoi(rawID); err != nil {
+		return fmt.Errorf("invalid id %s", rawID)
+	}
+
+	fields, err := stringMap(rawObject)
+	if err != nil {
+		return fmt.Errorf("invalid object: %w", err)
+	}
+
+	fqid := collection + "/" + rawID
+	for field, value := range fields {
+		if err := d.addValue(fqid+"/"+field, value); err != nil {
+			return err
+		}
+	}
+	d[fqid+"/id"] = []byte(rawID)
+	return nil
+}
+
+func (d Dump) addValue(key string, value interface{}) error {
+	bs, err := json.Marshal(jsonValue(value))
+	if err != nil {
+		return fmt.Errorf("encoding value of %s: %w", key, err)
+	}
+	d[key] = bs
+	return nil
+}
+
+// stringMap converts a decoded yaml map to a map with string keys.
+//
+// Yaml maps with int keys, like ids, are decoded as map[interface{}]interface{}.
+func stringMap(value interface{}) (map[string]interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			out[fmt.Sprint(k)] = value
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("got %T, expected a map", value)
+	}
+}
+
+// jsonValue converts all yaml maps in value to maps, that can be encoded as
+// json.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m, _ := stringMap(v)
+		return jsonValue(m)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			out[k] = jsonValue(value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = jsonValue(value)
+		}
+		return out
+	default:
+		return value
+	}
+}
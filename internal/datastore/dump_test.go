@@ -0,0 +1,71 @@
+package datastore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/datastore"
+)
+
+func TestLoadDump(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		dump string
+	}{
+		{
+			"yaml",
+			`
+			motion:
+			  1:
+			    title: first
+			    tag_ids: [1, 2]
+			motion/2:
+			  title: second
+			  options: {a: 1}
+			motion/3/title: third
+			`,
+		},
+		{
+			"json",
+			`{
+				"motion": {"1": {"title": "first", "tag_ids": [1, 2]}},
+				"motion/2": {"title": "second", "options": {"a": 1}},
+				"motion/3/title": "third"
+			}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dump, err := datastore.LoadDump(strings.NewReader(strings.ReplaceAll(tt.dump, "\t\t\t", "")))
+			if err != nil {
+				t.Fatalf("LoadDump returned unexpected error: %v", err)
+			}
+
+			keys := []string{"motion/1/title", "motion/1/tag_ids", "motion/1/id", "motion/2/title", "motion/2/options", "motion/2/id", "motion/3/title", "motion/3/id", "motion/4/id"}
+			values, err := dump.Get(context.Background(), keys...)
+			if err != nil {
+				t.Fatalf("Get returned unexpected error: %v", err)
+			}
+
+			expect := []string{`"first"`, `[1,2]`, `1`, `"second"`, `{"a":1}`, `2`, `"third"`, `3`, ``}
+			for i, key := range keys {
+				if got := string(values[i]); got != expect[i] {
+					t.Errorf("Got %s for %s, expected %s", got, key, expect[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDumpInvalid(t *testing.T) {
+	for _, dump := range []string{
+		`motion: [1, 2]`,
+		`motion: {one: {title: first}}`,
+		`motion/one/title: first`,
+		`motion/1/title/more: first`,
+	} {
+		if _, err := datastore.LoadDump(strings.NewReader(dump)); err == nil {
+			t.Errorf("Got no error for dump `%s`", dump)
+		}
+	}
+}
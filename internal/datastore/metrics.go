@@ -0,0 +1,25 @@
+package datastore
+
+import "github.com/OpenSlides/openslides-permission-service/internal/metrics"
+
+var (
+	getManyRequests = metrics.NewCounter(
+		"datastore_get_many_requests_total",
+		"Number of get_many requests to the datastore reader by result (ok or error).",
+		"result",
+	)
+	getManyKeys = metrics.NewCounter(
+		"datastore_get_many_keys_total",
+		"Number of keys requested with get_many.",
+	)
+	getManyDuration = metrics.NewHistogram(
+		"datastore_get_many_duration_seconds",
+		"Time of get_many requests including retries.",
+		metrics.DefaultBuckets,
+	)
+	cacheKeys = metrics.NewCounter(
+		"datastore_cache_keys_total",
+		"Number of keys requested from the cache by result (hit or miss).",
+		"result",
+	)
+)
@@ -3,11 +3,16 @@
 package http
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+
+	"github.com/OpenSlides/openslides-permission-service/pkg/permission"
 )
 
 const prefix = "/internal/permission"
@@ -21,14 +26,14 @@ type IsAlloweder interface {
 //
 // It returns the string `true` or `false` that can be encoded as json.
 //
-// If an error happens, a json-error-string is returned with status code 500.
+// If an error happens, a json error object is returned. See jsonError.
 func IsAllowed(mux *http.ServeMux, provider IsAlloweder) {
 	mux.Handle(prefix+"/is_allowed", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
 		b, err := io.ReadAll(r.Body)
 		if err != nil {
-			jsonError(w, "Can't read request body: "+err.Error())
+			jsonError(w, requestError(fmt.Sprintf("Can't read request body: %v", err)))
 			return
 		}
 
@@ -38,14 +43,14 @@ func IsAllowed(mux *http.ServeMux, provider IsAlloweder) {
 			DataList [](map[string]json.RawMessage) `json:"data"`
 		}
 		if err := json.Unmarshal(b, &requestData); err != nil {
-			jsonError(w, fmt.Sprintf("Can not decode request body '%s': %v", b, err))
+			jsonError(w, requestError(fmt.Sprintf("Can not decode request body '%s': %v", b, err)))
 			return
 		}
 
 		allowed, err := provider.IsAllowed(r.Context(), requestData.Name, requestData.UserID, requestData.DataList)
 
 		if err != nil {
-			jsonError(w, err.Error())
+			jsonError(w, err)
 			return
 		}
 
@@ -57,6 +62,419 @@ func IsAllowed(mux *http.ServeMux, provider IsAlloweder) {
 	}))
 }
 
+// PerPayloadIsAlloweder provides the IsAllowedPerPayload method.
+type PerPayloadIsAlloweder interface {
+	IsAllowedPerPayload(ctx context.Context, name string, userID int, dataList [](map[string]json.RawMessage)) ([]permission.PayloadResult, error)
+}
+
+// IsAllowedPerPayload registers a handler, to connect to the
+// IsAllowedPerPayload method.
+//
+// It expects the same request body as IsAllowed. It returns a json list with
+// one object for each payload. The object has the field allowed and, if the
+// payload is not allowed, a field reason or a field error. The error has the
+// same format as in jsonError.
+//
+// If the request fails as a whole, a json error object is returned. See
+// jsonError.
+func IsAllowedPerPayload(mux *http.ServeMux, provider PerPayloadIsAlloweder) {
+	mux.Handle(prefix+"/is_allowed_per_payload", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can't read request body: %v", err)))
+			return
+		}
+
+		var requestData struct {
+			Name     string                         `json:"name"`
+			UserID   int                            `json:"user_id"`
+			DataList [](map[string]json.RawMessage) `json:"data"`
+		}
+		if err := json.Unmarshal(b, &requestData); err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can not decode request body '%s': %v", b, err)))
+			return
+		}
+
+		results, err := provider.IsAllowedPerPayload(r.Context(), requestData.Name, requestData.UserID, requestData.DataList)
+		if err != nil {
+			jsonError(w, err)
+			return
+		}
+
+		type payloadResult struct {
+			Allowed bool         `json:"allowed"`
+			Reason  string       `json:"reason,omitempty"`
+			Error   *errorObject `json:"error,omitempty"`
+		}
+
+		out := make([]payloadResult, len(results))
+		for i, result := range results {
+			out[i] = payloadResult{Allowed: result.Allowed, Reason: result.Reason}
+			if result.Err != nil {
+				_, info := errorInfo(result.Err)
+				out[i].Error = &info
+			}
+		}
+
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			// The status code was already written.
+			return
+		}
+	}))
+}
+
+// Restricter provides the RestrictFQFields and RestrictFQIDs methods.
+type Restricter interface {
+	RestrictFQFields(ctx context.Context, userID int, fqfields []string) (map[string]bool, error)
+	RestrictFQIDs(ctx context.Context, userID int, fqids []string) (map[string][]string, error)
+}
+
+// RestrictFQFields registers a handler, to connect to the RestrictFQFields
+// method.
+//
+// It expects a json object with the field user_id and the fields fqfields or
+// fqids or both. For each fqid, all fields of the object are checked.
+//
+// It returns a sorted json list of all fqfields that the user can see. The
+// result is calculated completely before it is written.
+//
+// If an error happens, a json error object is returned. See jsonError.
+func RestrictFQFields(mux *http.ServeMux, provider Restricter) {
+	mux.Handle(prefix+"/restrict_fqfields", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can't read request body: %v", err)))
+			return
+		}
+
+		var requestData struct {
+			UserID   int      `json:"user_id"`
+			FQFields []string `json:"fqfields"`
+			FQIDs    []string `json:"fqids"`
+		}
+		if err := json.Unmarshal(b, &requestData); err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can not decode request body '%s': %v", b, err)))
+			return
+		}
+
+		allowed := make(map[string]bool)
+		if len(requestData.FQFields) > 0 {
+			allowed, err = provider.RestrictFQFields(r.Context(), requestData.UserID, requestData.FQFields)
+			if err != nil {
+				jsonError(w, err)
+				return
+			}
+		}
+
+		if len(requestData.FQIDs) > 0 {
+			if allowed == nil {
+				allowed = make(map[string]bool)
+			}
+
+			visible, err := provider.RestrictFQIDs(r.Context(), requestData.UserID, requestData.FQIDs)
+			if err != nil {
+				jsonError(w, err)
+				return
+			}
+
+			for fqid, fields := range visible {
+				for _, field := range fields {
+					allowed[fqid+"/"+field] = true
+				}
+			}
+		}
+
+		if err := writeFQFields(w, allowed); err != nil {
+			// The status code and maybe some data was already written. The only
+			// thing that can be done is to stop the response.
+			return
+		}
+	}))
+}
+
+// FQIDRestricter provides the RestrictFQIDs method.
+type FQIDRestricter interface {
+	RestrictFQIDs(ctx context.Context, userID int, fqids []string) (map[string][]string, error)
+}
+
+// RestrictFQIDs registers a handler, to connect to the RestrictFQIDs method.
+//
+// It expects a json object with the fields user_id and fqids and returns a
+// json object with the visible fields for each requested fqid.
+//
+// If an error happens, a json error object is returned. See jsonError.
+func RestrictFQIDs(mux *http.ServeMux, provider FQIDRestricter) {
+	mux.Handle(prefix+"/restrict_fqids", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can't read request body: %v", err)))
+			return
+		}
+
+		var requestData struct {
+			UserID int      `json:"user_id"`
+			FQIDs  []string `json:"fqids"`
+		}
+		if err := json.Unmarshal(b, &requestData); err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can not decode request body '%s': %v", b, err)))
+			return
+		}
+
+		visible, err := provider.RestrictFQIDs(r.Context(), requestData.UserID, requestData.FQIDs)
+		if err != nil {
+			jsonError(w, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(visible); err != nil {
+			// The status code was already written.
+			return
+		}
+	}))
+}
+
+// ValueRestricter provides the RestrictValues method.
+type ValueRestricter interface {
+	RestrictValues(ctx context.Context, userID int, values map[string]json.RawMessage) (map[string]json.RawMessage, error)
+}
+
+// RestrictValues registers a handler, to connect to the RestrictValues method.
+//
+// It expects a json object with the fields user_id and values, where values is
+// an object from fqfields to their values. It returns a json object with the
+// visible fqfields and their filtered values.
+//
+// If an error happens, a json error object is returned. See jsonError.
+func RestrictValues(mux *http.ServeMux, provider ValueRestricter) {
+	mux.Handle(prefix+"/restrict_values", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can't read request body: %v", err)))
+			return
+		}
+
+		var requestData struct {
+			UserID int                        `json:"user_id"`
+			Values map[string]json.RawMessage `json:"values"`
+		}
+		if err := json.Unmarshal(b, &requestData); err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can not decode request body '%s': %v", b, err)))
+			return
+		}
+
+		visible, err := provider.RestrictValues(r.Context(), requestData.UserID, requestData.Values)
+		if err != nil {
+			jsonError(w, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(visible); err != nil {
+			// The status code was already written.
+			return
+		}
+	}))
+}
+
+// writeFQFields writes all fqfields from the set as json list to w.
+//
+// The fqfields are sorted, so the response is the same on every call.
+func writeFQFields(w io.Writer, fqfields map[string]bool) error {
+	sorted := make([]string, 0, len(fqfields))
+	for k, v := range fqfields {
+		if v {
+			sorted = append(sorted, k)
+		}
+	}
+	sort.Strings(sorted)
+
+	buf := bufio.NewWriter(w)
+	if _, err := buf.WriteString("["); err != nil {
+		return fmt.Errorf("writing list start: %w", err)
+	}
+
+	for i, fqfield := range sorted {
+		if i > 0 {
+			if err := buf.WriteByte(','); err != nil {
+				return fmt.Errorf("writing separator: %w", err)
+			}
+		}
+
+		bs, err := json.Marshal(fqfield)
+		if err != nil {
+			return fmt.Errorf("encoding fqfield %s: %w", fqfield, err)
+		}
+
+		if _, err := buf.Write(bs); err != nil {
+			return fmt.Errorf("writing fqfield %s: %w", fqfield, err)
+		}
+	}
+
+	if _, err := buf.WriteString("]\n"); err != nil {
+		return fmt.Errorf("writing list end: %w", err)
+	}
+	return buf.Flush()
+}
+
+// EffectivePermissioner provides the EffectivePermissions method.
+type EffectivePermissioner interface {
+	EffectivePermissions(ctx context.Context, userID, meetingID int) (permission.EffectivePermissions, error)
+}
+
+// EffectivePermissions registers a handler, to connect to the
+// EffectivePermissions method.
+//
+// It expects a json object with the fields user_id and meeting_id and returns
+// the groups and permissions of the user in the meeting as json object.
+//
+// If an error happens, a json error object is returned. See jsonError.
+func EffectivePermissions(mux *http.ServeMux, provider EffectivePermissioner) {
+	mux.Handle(prefix+"/effective_permissions", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can't read request body: %v", err)))
+			return
+		}
+
+		var requestData struct {
+			UserID    int `json:"user_id"`
+			MeetingID int `json:"meeting_id"`
+		}
+		if err := json.Unmarshal(b, &requestData); err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can not decode request body '%s': %v", b, err)))
+			return
+		}
+
+		eff, err := provider.EffectivePermissions(r.Context(), requestData.UserID, requestData.MeetingID)
+		if err != nil {
+			jsonError(w, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(eff); err != nil {
+			// The status code was already written.
+			return
+		}
+	}))
+}
+
+// Invalidater provides the Invalidate method.
+type Invalidater interface {
+	Invalidate(keys ...string)
+}
+
+// Invalidate registers a handler, to remove keys from a cache.
+//
+// It expects a POST request with a json list of keys, that changed in the
+// datastore.
+//
+// If an error happens, a json error object is returned. See jsonError.
+func Invalidate(mux *http.ServeMux, cache Invalidater) {
+	mux.Handle(prefix+"/invalidate", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can't read request body: %v", err)))
+			return
+		}
+
+		var keys []string
+		if err := json.Unmarshal(b, &keys); err != nil {
+			jsonError(w, requestError(fmt.Sprintf("Can not decode request body '%s': %v", b, err)))
+			return
+		}
+
+		cache.Invalidate(keys...)
+		fmt.Fprintln(w, "true")
+	}))
+}
+
+// Metrics registers a handler, that returns the metrics of the service in
+// the prometheus text exposition format.
+func Metrics(mux *http.ServeMux, registry io.WriterTo) {
+	mux.Handle(prefix+"/metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		if _, err := registry.WriteTo(w); err != nil {
+			// The status code was already written.
+			return
+		}
+	}))
+}
+
+// LevelSetter provides the Level and SetLevel methods.
+type LevelSetter interface {
+	Level() permission.Level
+	SetLevel(permission.Level)
+}
+
+// LogLevel registers a handler, to read or change the log level at runtime.
+//
+// A GET request returns the current level as json object in the form
+// {"level": "info"}. A POST request with an object in the same form sets the
+// level and returns the new level.
+//
+// If an error happens, a json error object is returned. See jsonError.
+func LogLevel(mux *http.ServeMux, logger LevelSetter) {
+	mux.Handle(prefix+"/log_level", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var data struct {
+			Level string `json:"level"`
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+
+		case http.MethodPost:
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				jsonError(w, requestError(fmt.Sprintf("Can't read request body: %v", err)))
+				return
+			}
+
+			if err := json.Unmarshal(b, &data); err != nil {
+				jsonError(w, requestError(fmt.Sprintf("Can not decode request body '%s': %v", b, err)))
+				return
+			}
+
+			level, err := permission.ParseLevel(data.Level)
+			if err != nil {
+				jsonError(w, requestError(err.Error()))
+				return
+			}
+			logger.SetLevel(level)
+
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		data.Level = logger.Level().String()
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			// The status code was already written.
+			return
+		}
+	}))
+}
+
 type allrouter interface {
 	AllRoutes() ([]string, []string)
 }
@@ -78,19 +496,73 @@ func Health(mux *http.ServeMux, router allrouter) {
 		}
 		rData.Info.Routes.Collections, rData.Info.Routes.Actions = router.AllRoutes()
 		if err := json.NewEncoder(w).Encode(rData); err != nil {
-			jsonError(w, "Something went wrong")
+			jsonError(w, fmt.Errorf("encoding health info: %w", err))
 			return
 		}
 	}))
 }
 
+// requestError is returned, when the request body can not be read or decoded.
+type requestError string
+
+func (e requestError) Error() string {
+	return string(e)
+}
+
+func (e requestError) Type() string {
+	return "invalid"
+}
+
+// errorStatus maps the type of an error to the http status code.
+var errorStatus = map[string]int{
+	"invalid":   http.StatusBadRequest,
+	"not_found": http.StatusNotFound,
+	"datastore": http.StatusServiceUnavailable,
+	"internal":  http.StatusInternalServerError,
+}
+
 // jsonError writes an error to the client as json object.
-func jsonError(w http.ResponseWriter, msg string) {
-	b, err := json.Marshal("Internal Error. Norman, Do not sent it to client: " + msg)
+//
+// The object has the form {"error": {"type": "invalid", "msg": "..."}}. The
+// type is taken from the Type() method of the error and is one of invalid,
+// not_found, datastore or internal. Errors without a type are internal errors.
+func jsonError(w http.ResponseWriter, err error) {
+	status, info := errorInfo(err)
+
+	var body struct {
+		Error errorObject `json:"error"`
+	}
+	body.Error = info
+
+	b, err := json.Marshal(body)
 	if err != nil {
-		b = []byte(`"Very internal error"`)
+		b = []byte(`{"error":{"type":"internal","msg":"Very internal error"}}`)
 	}
 
-	w.WriteHeader(500)
+	w.WriteHeader(status)
 	w.Write(b)
 }
+
+// errorObject is the json representation of an error.
+type errorObject struct {
+	Type string `json:"type"`
+	Msg  string `json:"msg"`
+}
+
+// errorInfo returns the http status code and the json representation of an
+// error.
+func errorInfo(err error) (int, errorObject) {
+	errType := "internal"
+	var typer interface{ Type() string }
+	if errors.As(err, &typer) {
+		errType = typer.Type()
+	}
+
+	status, ok := errorStatus[errType]
+	if !ok {
+		errType = "internal"
+		status = http.StatusInternalServerError
+	}
+
+	return status, errorObject{Type: errType, Msg: err.Error()}
+}
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	permHTTP "github.com/OpenSlides/openslides-permission-service/internal/http"
+	"github.com/OpenSlides/openslides-permission-service/pkg/permission"
 )
 
 func TestHttpIsAllowed(t *testing.T) {
@@ -26,6 +27,7 @@ func TestHttpIsAllowed(t *testing.T) {
 		err     error
 
 		expectResponse    string
+		expectPrefix      bool
 		expectStatuseCode int
 	}{
 		{
@@ -49,9 +51,44 @@ func TestHttpIsAllowed(t *testing.T) {
 
 			err: fmt.Errorf("something happend :("),
 
-			expectResponse:    `"Internal Error. Norman, Do not sent it to client: something happend :("`,
+			expectResponse:    `{"error":{"type":"internal","msg":"something happend :("}}`,
 			expectStatuseCode: 500,
 		},
+		{
+			name:    "Invalid payload",
+			reqBody: `{"name": "everything", "user_id": 1}`,
+
+			err: typedError{"invalid", "id is missing"},
+
+			expectResponse:    `{"error":{"type":"invalid","msg":"id is missing"}}`,
+			expectStatuseCode: 400,
+		},
+		{
+			name:    "Unknown action",
+			reqBody: `{"name": "everything", "user_id": 1}`,
+
+			err: fmt.Errorf("wrapped: %w", typedError{"not_found", "unknown action"}),
+
+			expectResponse:    `{"error":{"type":"not_found","msg":"wrapped: unknown action"}}`,
+			expectStatuseCode: 404,
+		},
+		{
+			name:    "Datastore Error",
+			reqBody: `{"name": "everything", "user_id": 1}`,
+
+			err: typedError{"datastore", "datastore is down"},
+
+			expectResponse:    `{"error":{"type":"datastore","msg":"datastore is down"}}`,
+			expectStatuseCode: 503,
+		},
+		{
+			name:    "Invalid body",
+			reqBody: `{"name": "everything", "user_id": 1`,
+
+			expectResponse:    `{"error":{"type":"invalid","msg":"Can not decode request body`,
+			expectPrefix:      true,
+			expectStatuseCode: 400,
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			allowed.allowed = tt.allowed
@@ -69,6 +106,100 @@ func TestHttpIsAllowed(t *testing.T) {
 				t.Errorf("Got status %s, expected %s", resp.Result().Status, http.StatusText(tt.expectStatuseCode))
 			}
 
+			bodyBytes, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Cannot read response: %v", err)
+			}
+			body := strings.TrimSpace(string(bodyBytes))
+			if tt.expectPrefix {
+				if !strings.HasPrefix(body, tt.expectResponse) {
+					t.Errorf("Got '%s', expected it to start with '%s'", body, tt.expectResponse)
+				}
+				return
+			}
+
+			if body != tt.expectResponse {
+				t.Errorf("Got '%s', expected '%s'", body, tt.expectResponse)
+			}
+		})
+	}
+}
+
+func TestHttpRestrictFQFields(t *testing.T) {
+	mux := http.NewServeMux()
+	restricter := new(RestricterMock)
+	permHTTP.RestrictFQFields(mux, restricter)
+
+	for _, tt := range []struct {
+		name string
+
+		reqBody string
+		allowed map[string]bool
+		visible map[string][]string
+		err     error
+
+		expectResponse    string
+		expectStatuseCode int
+	}{
+		{
+			name:    "Some fields",
+			reqBody: `{"user_id": 1, "fqfields": ["motion/1/title", "motion/1/text", "motion/2/title"]}`,
+			allowed: map[string]bool{"motion/2/title": true, "motion/1/title": true, "motion/1/text": false},
+
+			expectResponse:    `["motion/1/title","motion/2/title"]`,
+			expectStatuseCode: 200,
+		},
+		{
+			name:    "No fields",
+			reqBody: `{"user_id": 1, "fqfields": ["motion/1/title"]}`,
+
+			expectResponse:    `[]`,
+			expectStatuseCode: 200,
+		},
+		{
+			name:    "FQIDs",
+			reqBody: `{"user_id": 1, "fqids": ["motion/1", "motion/2"]}`,
+			visible: map[string][]string{"motion/1": {"id", "title"}, "motion/2": {}},
+
+			expectResponse:    `["motion/1/id","motion/1/title"]`,
+			expectStatuseCode: 200,
+		},
+		{
+			name:    "FQFields and FQIDs",
+			reqBody: `{"user_id": 1, "fqfields": ["motion/2/title"], "fqids": ["motion/1"]}`,
+			allowed: map[string]bool{"motion/2/title": true},
+			visible: map[string][]string{"motion/1": {"id"}},
+
+			expectResponse:    `["motion/1/id","motion/2/title"]`,
+			expectStatuseCode: 200,
+		},
+		{
+			name:    "Internal Error",
+			reqBody: `{"user_id": 1, "fqfields": ["motion/1/title"]}`,
+
+			err: fmt.Errorf("something happend :("),
+
+			expectResponse:    `{"error":{"type":"internal","msg":"something happend :("}}`,
+			expectStatuseCode: 500,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			restricter.allowed = tt.allowed
+			restricter.visible = tt.visible
+			restricter.err = tt.err
+
+			req, err := http.NewRequest("POST", "/internal/permission/restrict_fqfields", strings.NewReader(tt.reqBody))
+			if err != nil {
+				t.Fatalf("Creating request: %v", err)
+			}
+
+			resp := httptest.NewRecorder()
+			mux.ServeHTTP(resp, req)
+
+			if resp.Result().StatusCode != tt.expectStatuseCode {
+				t.Errorf("Got status %s, expected %s", resp.Result().Status, http.StatusText(tt.expectStatuseCode))
+			}
+
 			bodyBytes, err := io.ReadAll(resp.Body)
 			if err != nil {
 				t.Fatalf("Cannot read response: %v", err)
@@ -89,3 +220,301 @@ type IsAllowedMock struct {
 func (a *IsAllowedMock) IsAllowed(ctx context.Context, name string, userID int, data [](map[string]json.RawMessage)) (bool, error) {
 	return a.allowed, a.err
 }
+
+// typedError is an error with a Type() method like the errors from the
+// permission package.
+type typedError struct {
+	typ string
+	msg string
+}
+
+func (e typedError) Error() string {
+	return e.msg
+}
+
+func (e typedError) Type() string {
+	return e.typ
+}
+
+type RestricterMock struct {
+	allowed map[string]bool
+	visible map[string][]string
+	err     error
+}
+
+func (r *RestricterMock) RestrictFQFields(ctx context.Context, userID int, fqfields []string) (map[string]bool, error) {
+	return r.allowed, r.err
+}
+
+func (r *RestricterMock) RestrictFQIDs(ctx context.Context, userID int, fqids []string) (map[string][]string, error) {
+	return r.visible, r.err
+}
+
+func TestHttpInvalidate(t *testing.T) {
+	mux := http.NewServeMux()
+	cache := new(InvalidaterMock)
+	permHTTP.Invalidate(mux, cache)
+
+	req, err := http.NewRequest("POST", "/internal/permission/invalidate", strings.NewReader(`["group/1/permissions","user/1/group_$1_ids"]`))
+	if err != nil {
+		t.Fatalf("Creating request: %v", err)
+	}
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	if resp.Result().StatusCode != 200 {
+		t.Errorf("Got status %s, expected 200 OK", resp.Result().Status)
+	}
+
+	if got := strings.Join(cache.keys, ","); got != "group/1/permissions,user/1/group_$1_ids" {
+		t.Errorf("Got invalidated keys %s", got)
+	}
+}
+
+type InvalidaterMock struct {
+	keys []string
+}
+
+func (i *InvalidaterMock) Invalidate(keys ...string) {
+	i.keys = append(i.keys, keys...)
+}
+
+func TestHttpEffectivePermissions(t *testing.T) {
+	mux := http.NewServeMux()
+	provider := &EffectivePermissionerMock{eff: permission.EffectivePermissions{
+		UserID:      1,
+		MeetingID:   2,
+		InMeeting:   true,
+		GroupIDs:    []int{3},
+		Permissions: []string{"motion.can_see"},
+	}}
+	permHTTP.EffectivePermissions(mux, provider)
+
+	req, err := http.NewRequest("POST", "/internal/permission/effective_permissions", strings.NewReader(`{"user_id": 1, "meeting_id": 2}`))
+	if err != nil {
+		t.Fatalf("Creating request: %v", err)
+	}
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	if resp.Result().StatusCode != 200 {
+		t.Errorf("Got status %s, expected 200 OK", resp.Result().Status)
+	}
+
+	if provider.userID != 1 || provider.meetingID != 2 {
+		t.Errorf("Got user %d and meeting %d, expected 1 and 2", provider.userID, provider.meetingID)
+	}
+
+	expect := `{"user_id":1,"meeting_id":2,"superadmin":false,"in_meeting":true,"admin":false,"anonymous":false,"group_ids":[3],"permissions":["motion.can_see"]}`
+	if got := strings.TrimSpace(resp.Body.String()); got != expect {
+		t.Errorf("Got '%s', expected '%s'", got, expect)
+	}
+}
+
+type EffectivePermissionerMock struct {
+	eff       permission.EffectivePermissions
+	userID    int
+	meetingID int
+}
+
+func (e *EffectivePermissionerMock) EffectivePermissions(ctx context.Context, userID, meetingID int) (permission.EffectivePermissions, error) {
+	e.userID = userID
+	e.meetingID = meetingID
+	return e.eff, nil
+}
+
+func TestHttpRestrictFQIDs(t *testing.T) {
+	mux := http.NewServeMux()
+	provider := &FQIDRestricterMock{visible: map[string][]string{
+		"motion/1": {"id", "title"},
+		"motion/2": {},
+	}}
+	permHTTP.RestrictFQIDs(mux, provider)
+
+	req, err := http.NewRequest("POST", "/internal/permission/restrict_fqids", strings.NewReader(`{"user_id": 1, "fqids": ["motion/1", "motion/2"]}`))
+	if err != nil {
+		t.Fatalf("Creating request: %v", err)
+	}
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	if resp.Result().StatusCode != 200 {
+		t.Errorf("Got status %s, expected 200 OK", resp.Result().Status)
+	}
+
+	if got := strings.Join(provider.fqids, ","); got != "motion/1,motion/2" {
+		t.Errorf("Got fqids %s, expected motion/1,motion/2", got)
+	}
+
+	expect := `{"motion/1":["id","title"],"motion/2":[]}`
+	if got := strings.TrimSpace(resp.Body.String()); got != expect {
+		t.Errorf("Got '%s', expected '%s'", got, expect)
+	}
+}
+
+type FQIDRestricterMock struct {
+	visible map[string][]string
+	fqids   []string
+}
+
+func (r *FQIDRestricterMock) RestrictFQIDs(ctx context.Context, userID int, fqids []string) (map[string][]string, error) {
+	r.fqids = fqids
+	return r.visible, nil
+}
+
+func TestHttpRestrictValues(t *testing.T) {
+	mux := http.NewServeMux()
+	provider := &ValueRestricterMock{visible: map[string]json.RawMessage{
+		"agenda_item/1/child_ids": []byte("[3]"),
+	}}
+	permHTTP.RestrictValues(mux, provider)
+
+	req, err := http.NewRequest("POST", "/internal/permission/restrict_values", strings.NewReader(`{"user_id": 1, "values": {"agenda_item/1/child_ids": [2,3], "agenda_item/2/id": 2}}`))
+	if err != nil {
+		t.Fatalf("Creating request: %v", err)
+	}
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	if resp.Result().StatusCode != 200 {
+		t.Errorf("Got status %s, expected 200 OK", resp.Result().Status)
+	}
+
+	if len(provider.values) != 2 || string(provider.values["agenda_item/1/child_ids"]) != "[2,3]" {
+		t.Errorf("Got values %v, expected the two requested values", provider.values)
+	}
+
+	expect := `{"agenda_item/1/child_ids":[3]}`
+	if got := strings.TrimSpace(resp.Body.String()); got != expect {
+		t.Errorf("Got '%s', expected '%s'", got, expect)
+	}
+}
+
+type ValueRestricterMock struct {
+	visible map[string]json.RawMessage
+	values  map[string]json.RawMessage
+}
+
+func (r *ValueRestricterMock) RestrictValues(ctx context.Context, userID int, values map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	r.values = values
+	return r.visible, nil
+}
+
+func TestHttpIsAllowedPerPayload(t *testing.T) {
+	mux := http.NewServeMux()
+	provider := &PerPayloadMock{results: []permission.PayloadResult{
+		{Allowed: true},
+		{Reason: "not your motion"},
+		{Err: typedError{"invalid", "id is missing"}},
+	}}
+	permHTTP.IsAllowedPerPayload(mux, provider)
+
+	req, err := http.NewRequest("POST", "/internal/permission/is_allowed_per_payload", strings.NewReader(`{"name": "motion.update", "user_id": 1, "data": [{}, {}, {}]}`))
+	if err != nil {
+		t.Fatalf("Creating request: %v", err)
+	}
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	if resp.Result().StatusCode != 200 {
+		t.Errorf("Got status %s, expected 200 OK", resp.Result().Status)
+	}
+
+	if provider.payloads != 3 {
+		t.Errorf("Got %d payloads, expected 3", provider.payloads)
+	}
+
+	expect := `[{"allowed":true},{"allowed":false,"reason":"not your motion"},{"allowed":false,"error":{"type":"invalid","msg":"id is missing"}}]`
+	if got := strings.TrimSpace(resp.Body.String()); got != expect {
+		t.Errorf("Got '%s', expected '%s'", got, expect)
+	}
+}
+
+type PerPayloadMock struct {
+	results  []permission.PayloadResult
+	payloads int
+}
+
+func (p *PerPayloadMock) IsAllowedPerPayload(ctx context.Context, name string, userID int, data [](map[string]json.RawMessage)) ([]permission.PayloadResult, error) {
+	p.payloads = len(data)
+	return p.results, nil
+}
+
+func TestHttpMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	permHTTP.Metrics(mux, strings.NewReader("some_metric 1\n"))
+
+	req, err := http.NewRequest("GET", "/internal/permission/metrics", nil)
+	if err != nil {
+		t.Fatalf("Creating request: %v", err)
+	}
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	if resp.Result().StatusCode != 200 {
+		t.Errorf("Got status %s, expected 200 OK", resp.Result().Status)
+	}
+
+	if got := resp.Body.String(); got != "some_metric 1\n" {
+		t.Errorf("Got '%s', expected 'some_metric 1'", got)
+	}
+}
+
+func TestHttpLogLevel(t *testing.T) {
+	mux := http.NewServeMux()
+	logger := &LevelSetterMock{level: permission.LevelInfo}
+	permHTTP.LogLevel(mux, logger)
+
+	for _, tt := range []struct {
+		name   string
+		method string
+		body   string
+		status int
+		expect string
+		level  permission.Level
+	}{
+		{"Get", "GET", "", 200, `{"level":"info"}`, permission.LevelInfo},
+		{"Set", "POST", `{"level":"debug"}`, 200, `{"level":"debug"}`, permission.LevelDebug},
+		{"Unknown level", "POST", `{"level":"verbose"}`, 400, `{"error":{"type":"invalid","msg":"unknown log level ` + "`verbose`" + `"}}`, permission.LevelDebug},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "/internal/permission/log_level", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Creating request: %v", err)
+			}
+
+			resp := httptest.NewRecorder()
+			mux.ServeHTTP(resp, req)
+
+			if resp.Result().StatusCode != tt.status {
+				t.Errorf("Got status %s, expected %d", resp.Result().Status, tt.status)
+			}
+
+			if got := strings.TrimSpace(resp.Body.String()); got != tt.expect {
+				t.Errorf("Got `%s`, expected `%s`", got, tt.expect)
+			}
+
+			if logger.level != tt.level {
+				t.Errorf("Got level %s, expected %s", logger.level, tt.level)
+			}
+		})
+	}
+}
+
+type LevelSetterMock struct {
+	level permission.Level
+}
+
+func (l *LevelSetterMock) Level() permission.Level {
+	return l.level
+}
+
+func (l *LevelSetterMock) SetLevel(level permission.Level) {
+	l.level = level
+}
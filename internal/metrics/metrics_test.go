@@ -0,0 +1,59 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/metrics"
+)
+
+func TestRegistry(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.NewCounter("requests_total", "Number of requests.", "action", "result")
+	h := r.NewHistogram("duration_seconds", "Duration of requests.", []float64{0.1, 1})
+	plain := r.NewCounter("plain_total", "Counter without labels.")
+
+	c.Inc("motion.update", "allowed")
+	c.Inc("motion.update", "allowed")
+	c.Add(3, "motion.delete", `de"nied`)
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+	plain.Inc()
+
+	var buf strings.Builder
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo returned unexpected error: %v", err)
+	}
+
+	expect := `# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total{action="motion.delete",result="de\"nied"} 3
+requests_total{action="motion.update",result="allowed"} 2
+# HELP duration_seconds Duration of requests.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{le="0.1"} 1
+duration_seconds_bucket{le="1"} 2
+duration_seconds_bucket{le="+Inf"} 3
+duration_seconds_sum 5.55
+duration_seconds_count 3
+# HELP plain_total Counter without labels.
+# TYPE plain_total counter
+plain_total 1
+`
+	if got := buf.String(); got != expect {
+		t.Errorf("Got:\n%s\nExpected:\n%s", got, expect)
+	}
+}
+
+func TestRegistryDuplicateName(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.NewCounter("requests_total", "Number of requests.")
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Got no panic for duplicate metric name")
+		}
+	}()
+	r.NewHistogram("requests_total", "Number of requests.", nil)
+}
@@ -1,5 +1,5 @@
 // Code generated with models.txt DO NOT EDIT.
-package tests
+package models
 
 var collectionFields = map[string][]string{
 	"agenda_item":                  {"child_ids", "closed", "comment", "content_object_id", "current_projector_ids", "duration", "id", "is_hidden", "is_internal", "item_number", "level", "meeting_id", "parent_id", "projection_ids", "tag_ids", "type", "weight"},
@@ -0,0 +1,108 @@
+// This tool generates the list of all actions of the backend.
+//
+// It requires a running backend. The url of the health route can be given as
+// first argument.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"text/template"
+)
+
+const defaultURL = "http://localhost:9002/health"
+
+// additionalActions are actions that are not in the health route of the
+// backend. These are internal actions of the backend and actions that are used
+// by other services.
+var additionalActions = []string{
+	"list_of_speakers.delete",
+	"mediafile.can_see_mediafile",
+	"option.delete",
+	"poll.vote",
+	"vote.delete",
+}
+
+func main() {
+	url := defaultURL
+	if len(os.Args) > 1 {
+		url = os.Args[1]
+	}
+
+	r, err := loadHealth(url)
+	if err != nil {
+		log.Fatalf("Can not load backend health: %v", err)
+	}
+	defer r.Close()
+
+	data, err := parse(r)
+	if err != nil {
+		log.Fatalf("Can not parse backend health: %v", err)
+	}
+
+	if err := writeFile(os.Stdout, data); err != nil {
+		log.Fatalf("Can not write result: %v", err)
+	}
+}
+
+func loadHealth(url string) (io.ReadCloser, error) {
+	r, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("request health: %w", err)
+	}
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request returned status %s", r.Status)
+	}
+	return r.Body, nil
+}
+
+// parse returns the sorted names of all actions.
+func parse(r io.Reader) ([]string, error) {
+	var health struct {
+		Info struct {
+			Actions map[string]json.RawMessage `json:"actions"`
+		} `json:"healthinfo"`
+	}
+	if err := json.NewDecoder(r).Decode(&health); err != nil {
+		return nil, fmt.Errorf("decoding health: %w", err)
+	}
+
+	actions := append([]string{}, additionalActions...)
+	for name := range health.Info.Actions {
+		actions = append(actions, name)
+	}
+	sort.Strings(actions)
+	return actions, nil
+}
+
+const tpl = `// Code generated with gen_actions/main.go DO NOT EDIT.
+package models
+
+var backendActions = []string{
+	{{- range $v := .Actions}}
+	"{{$v}}",
+	{{- end}}
+}
+`
+
+func writeFile(w io.Writer, actions []string) error {
+	t := template.New("t")
+	t, err := t.Parse(tpl)
+	if err != nil {
+		return fmt.Errorf("parsing template: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"Actions": actions,
+	}
+
+	if err := t.Execute(w, data); err != nil {
+		return fmt.Errorf("writing template: %w", err)
+	}
+	return nil
+}
@@ -65,7 +65,7 @@ func parse(r io.Reader) (map[string][]string, error) {
 }
 
 const tpl = `// Code generated with models.txt DO NOT EDIT.
-package tests
+package models
 
 var collectionFields = map[string][]string{
 	{{- range $key, $value := .Def}}
@@ -0,0 +1,60 @@
+// Package models knows the collections and fields of the OpenSlides models
+// and the actions of the backend.
+//
+// The fields are generated from the models.yml of the OpenSlides repository.
+// The actions are generated from the health route of a running backend.
+package models
+
+//go:generate  sh -c "go run gen_fields/main.go > fields.go && go fmt fields.go"
+//go:generate  sh -c "go run gen_actions/main.go > actions.go && go fmt actions.go"
+
+import (
+	"sort"
+	"strings"
+)
+
+// Fields returns the names of all fields of a collection in sorted order.
+//
+// Template fields are returned with there placeholder, for example
+// `group_$_ids`. The second return value is false, if the collection does not
+// exist.
+func Fields(collection string) ([]string, bool) {
+	fields, ok := collectionFields[collection]
+	if !ok {
+		return nil, false
+	}
+
+	out := make([]string, len(fields))
+	copy(out, fields)
+	return out, true
+}
+
+// Collections returns the names of all collections in sorted order.
+func Collections() []string {
+	out := make([]string, 0, len(collectionFields))
+	for k := range collectionFields {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Actions returns the names of all actions of the backend in sorted order.
+func Actions() []string {
+	out := make([]string, len(backendActions))
+	copy(out, backendActions)
+	return out
+}
+
+// IsTemplate returns true, if the field is a template field.
+func IsTemplate(field string) bool {
+	return strings.Contains(field, "$")
+}
+
+// TemplateField returns the field of a template for a replacement.
+//
+// For example the template `group_$_ids` with the replacement `5` becomes
+// `group_$5_ids`.
+func TemplateField(template, replacement string) string {
+	return strings.Replace(template, "$", "$"+replacement, 1)
+}
@@ -0,0 +1,16 @@
+package perm
+
+import "fmt"
+
+// InvalidPayloadError is returned, when the payload of an action does not have
+// the expected format.
+type InvalidPayloadError string
+
+func (e InvalidPayloadError) Error() string {
+	return "invalid payload: " + string(e)
+}
+
+// InvalidPayloadf creates an InvalidPayloadError with a formated message.
+func InvalidPayloadf(format string, a ...interface{}) error {
+	return InvalidPayloadError(fmt.Sprintf(format, a...))
+}
@@ -0,0 +1,69 @@
+package perm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type explanationKey struct{}
+
+// Explanation collects the reasons, why a permission check failed.
+//
+// It has to be created with WithExplanation.
+type Explanation struct {
+	mu      sync.Mutex
+	reasons []string
+}
+
+// WithExplanation returns a context with a new Explanation object.
+//
+// All reasons, given to LogNotAllowedf with the returned context, are saved in
+// the Explanation object.
+func WithExplanation(ctx context.Context) (context.Context, *Explanation) {
+	e := new(Explanation)
+	return context.WithValue(ctx, explanationKey{}, e), e
+}
+
+// Reasons returns all saved reasons in the order they were given.
+func (e *Explanation) Reasons() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	reasons := make([]string, len(e.reasons))
+	copy(reasons, e.reasons)
+	return reasons
+}
+
+func (e *Explanation) add(reason string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, r := range e.reasons {
+		if r == reason {
+			return
+		}
+	}
+	e.reasons = append(e.reasons, reason)
+}
+
+// LogNotAllowedf logs the reason of a permission failer.
+//
+// If the context was created with WithExplanation, the reason is saved in the
+// Explanation object. If the context was created with WithDenialLogger, the
+// reason is send to the logger.
+func LogNotAllowedf(ctx context.Context, format string, a ...interface{}) {
+	e, explain := ctx.Value(explanationKey{}).(*Explanation)
+	_, log := ctx.Value(denialLogKey{}).(DenialLogger)
+	if !explain && !log {
+		return
+	}
+
+	reason := fmt.Sprintf(format, a...)
+	if explain {
+		e.add(reason)
+	}
+	if log {
+		logDenial(ctx, reason)
+	}
+}
@@ -0,0 +1,41 @@
+package perm
+
+import (
+	"context"
+)
+
+type denialLogKey struct{}
+
+type logMeetingKey struct{}
+
+// DenialLogger receives the reasons, why a permission check failed.
+type DenialLogger interface {
+	// LogDenial is called for each reason given to LogNotAllowedf. The
+	// meetingID is the meeting set with WithLogMeeting or 0, if the context
+	// has no meeting.
+	LogDenial(meetingID int, reason string)
+}
+
+// WithDenialLogger returns a context, that sends all reasons given to
+// LogNotAllowedf to the logger.
+func WithDenialLogger(ctx context.Context, logger DenialLogger) context.Context {
+	return context.WithValue(ctx, denialLogKey{}, logger)
+}
+
+// WithLogMeeting returns a context, that logs all reasons given to
+// LogNotAllowedf with the given meeting id.
+//
+// It has to be called for each object or payload, after its meeting is known.
+func WithLogMeeting(ctx context.Context, meetingID int) context.Context {
+	return context.WithValue(ctx, logMeetingKey{}, meetingID)
+}
+
+func logDenial(ctx context.Context, reason string) {
+	logger, ok := ctx.Value(denialLogKey{}).(DenialLogger)
+	if !ok {
+		return
+	}
+
+	meetingID, _ := ctx.Value(logMeetingKey{}).(int)
+	logger.LogDenial(meetingID, reason)
+}
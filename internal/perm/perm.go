@@ -5,6 +5,7 @@ package perm
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
 )
@@ -12,6 +13,7 @@ import (
 // Permission holds the information which permissions and groups a user has.
 type Permission struct {
 	admin       bool
+	anonymous   bool
 	groupIDs    []int
 	permissions map[TPermission]bool
 }
@@ -50,6 +52,67 @@ func New(ctx context.Context, dp dataprovider.DataProvider, userID, meetingID in
 	return &Permission{groupIDs: groupIDs, permissions: perms}, nil
 }
 
+// Prefetch loads all keys, that are needed to call New() for the given user in
+// the given meetings, with two requests to the datastore.
+//
+// It only has an effect, if the context has a cache (see
+// dataprovider.WithCache).
+func Prefetch(ctx context.Context, dp dataprovider.DataProvider, userID int, meetingIDs ...int) error {
+	if len(meetingIDs) == 0 {
+		return nil
+	}
+
+	var keys []string
+	for _, mid := range meetingIDs {
+		if mid == 0 {
+			continue
+		}
+
+		if userID != 0 {
+			keys = append(keys, fmt.Sprintf("user/%d/group_$%d_ids", userID, mid))
+		}
+		keys = append(
+			keys,
+			fmt.Sprintf("meeting/%d/admin_group_id", mid),
+			fmt.Sprintf("meeting/%d/enable_anonymous", mid),
+			fmt.Sprintf("meeting/%d/default_group_id", mid),
+		)
+	}
+	if err := dp.Prefetch(ctx, keys...); err != nil {
+		return fmt.Errorf("prefetching meeting keys: %w", err)
+	}
+
+	keys = keys[:0]
+	for _, mid := range meetingIDs {
+		if mid == 0 {
+			continue
+		}
+
+		var groupIDs []int
+		if userID == 0 {
+			var defaultGroupID int
+			if err := dp.GetIfExist(ctx, fmt.Sprintf("meeting/%d/default_group_id", mid), &defaultGroupID); err != nil {
+				return fmt.Errorf("getting default group: %w", err)
+			}
+			if defaultGroupID != 0 {
+				groupIDs = append(groupIDs, defaultGroupID)
+			}
+		} else {
+			if err := dp.GetIfExist(ctx, fmt.Sprintf("user/%d/group_$%d_ids", userID, mid), &groupIDs); err != nil {
+				return fmt.Errorf("get group ids: %w", err)
+			}
+		}
+
+		for _, gid := range groupIDs {
+			keys = append(keys, fmt.Sprintf("group/%d/permissions", gid))
+		}
+	}
+	if err := dp.Prefetch(ctx, keys...); err != nil {
+		return fmt.Errorf("prefetching group permissions: %w", err)
+	}
+	return nil
+}
+
 func newAnonymous(ctx context.Context, dp dataprovider.DataProvider, meetingID int) (*Permission, error) {
 	var enableAnonymous bool
 	fqfield := fmt.Sprintf("meeting/%d/enable_anonymous", meetingID)
@@ -71,7 +134,7 @@ func newAnonymous(ctx context.Context, dp dataprovider.DataProvider, meetingID i
 		return nil, fmt.Errorf("getting permissions of default group: %w", err)
 	}
 
-	return &Permission{groupIDs: []int{defaultGroupID}, permissions: perms}, nil
+	return &Permission{anonymous: true, groupIDs: []int{defaultGroupID}, permissions: perms}, nil
 }
 
 func isAdmin(ctx context.Context, dp dataprovider.DataProvider, meetingID int, groupIDs []int) (bool, error) {
@@ -127,6 +190,43 @@ func (p *Permission) IsAdmin() bool {
 	return p.admin
 }
 
+// IsAnonymous returns true, if the permissions are from the anonymous user.
+func (p *Permission) IsAnonymous() bool {
+	return p.anonymous
+}
+
+// GroupIDs returns the sorted ids of the groups of the user in the meeting.
+//
+// The groups of a meeting admin are not loaded, so the list is empty.
+func (p *Permission) GroupIDs() []int {
+	ids := make([]int, len(p.groupIDs))
+	copy(ids, p.groupIDs)
+	sort.Ints(ids)
+	return ids
+}
+
+// Permissions returns all permissions of the user in sorted order.
+//
+// The list contains the permissions that are derivated from other
+// permissions. A meeting admin has all permissions.
+func (p *Permission) Permissions() []TPermission {
+	var perms []TPermission
+	if p.admin {
+		for k := range derivatePerms {
+			perms = append(perms, k)
+		}
+	} else {
+		for k, v := range p.permissions {
+			if v {
+				perms = append(perms, k)
+			}
+		}
+	}
+
+	sort.Slice(perms, func(i, j int) bool { return perms[i] < perms[j] })
+	return perms
+}
+
 // InGroup returns true, if the user is in the given group (by group_id).
 func (p *Permission) InGroup(gid int) bool {
 	for _, id := range p.groupIDs {
@@ -148,7 +248,7 @@ func HasPerm(ctx context.Context, dp dataprovider.DataProvider, userID int, meet
 
 	hasPerms := perm.Has(permission)
 	if !hasPerms {
-		LogNotAllowedf("User %d does not have the permission %s in meeting %d", userID, permission, meetingID)
+		LogNotAllowedf(WithLogMeeting(ctx, meetingID), "User %d does not have the permission %s in meeting %d", userID, permission, meetingID)
 		return false, nil
 	}
 
@@ -177,8 +277,3 @@ func AllFields(fqfields []FQField, result map[string]bool, f func(FQField) (bool
 	}
 	return nil
 }
-
-// LogNotAllowedf logs the permission failer.
-func LogNotAllowedf(format string, a ...interface{}) {
-	// log.Printf(format, a...)
-}
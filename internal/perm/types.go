@@ -36,6 +36,21 @@ func (f CollectionFunc) RestrictFQFields(ctx context.Context, userID int, fqfiel
 	return f(ctx, userID, fqfields, result)
 }
 
+// AffectedUsersFunc returns the ids of all users, whose permissions could have
+// changed, when the value of the given key changed.
+//
+// The argument updated contains all changed keys of the update with their new
+// values, so updated[key.String()] is the new value of the key. The value of a
+// deleted key is nil.
+type AffectedUsersFunc func(ctx context.Context, key FQField, updated map[string]json.RawMessage) ([]int, error)
+
+// ValueFilterFunc returns the part of the value of a field, that the user can
+// see. It is only called for fields, that the user can see.
+//
+// It is used for fields, that reference objects, where some of the objects can
+// be invisible for the user.
+type ValueFilterFunc func(ctx context.Context, userID int, fqfield FQField, value json.RawMessage) (json.RawMessage, error)
+
 // Connecter can connect Actions and Collections to a HandlerStore.
 type Connecter interface {
 	Connect(store HandlerStore)
@@ -53,6 +68,20 @@ func (f ConnecterFunc) Connect(store HandlerStore) {
 type HandlerStore interface {
 	RegisterRestricter(name string, collection Collection)
 	RegisterAction(name string, action Action)
+
+	// RegisterDependency registers a function that is called, when a field of
+	// the collection changes. For template fields, the field has to be the
+	// prefix ending with the $ (for example group_$).
+	RegisterDependency(collection, field string, f AffectedUsersFunc)
+
+	// RegisterValueFilter registers a function that filters the value of a
+	// field, after the field was restricted.
+	RegisterValueFilter(collection, field string, f ValueFilterFunc)
+
+	// Restricter returns the restricter of a collection. It can only be used
+	// after all Connecters are connected, for example while a permission is
+	// checked.
+	Restricter(name string) (Collection, bool)
 }
 
 // FQField contains all parts of a fqfield.
@@ -81,6 +110,17 @@ func ParseFQField(fqfield string) (FQField, error) {
 	}, nil
 }
 
+// TemplatePrefix returns the field name until the first $ including the $.
+//
+// If the field is not a template field, the field name is returned.
+func (fqfield FQField) TemplatePrefix() string {
+	i := strings.IndexByte(fqfield.Field, '$')
+	if i < 0 {
+		return fqfield.Field
+	}
+	return fqfield.Field[:i+1]
+}
+
 func (fqfield FQField) String() string {
 	return fmt.Sprintf("%s/%d/%s", fqfield.Collection, fqfield.ID, fqfield.Field)
 }
@@ -92,3 +132,9 @@ func (fqfield FQField) FQID() string {
 
 // TPermission is a type of all valid permission strings.
 type TPermission string
+
+// Valid returns true, if the permission is a known permission.
+func (p TPermission) Valid() bool {
+	_, ok := derivatePerms[p]
+	return ok
+}
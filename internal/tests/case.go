@@ -10,12 +10,12 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/OpenSlides/openslides-permission-service/internal/datastore"
+	"github.com/OpenSlides/openslides-permission-service/internal/models"
 	"github.com/OpenSlides/openslides-permission-service/pkg/permission"
 	"gopkg.in/yaml.v3"
 )
 
-//go:generate  sh -c "go run gen_fields/main.go > fields.go && go fmt fields.go"
-
 // Case object for testing.
 type Case struct {
 	Name     string
@@ -35,6 +35,10 @@ type Case struct {
 	CanSee    []string `yaml:"can_see"` // TODO: fix nil != undefined
 	CanNotSee []string `yaml:"can_not_see"`
 
+	// Values are the expected results of RestrictValues for the values of the
+	// fqfields in the db.
+	Values map[string]interface{}
+
 	Cases []*Case
 }
 
@@ -58,74 +62,16 @@ func (c *Case) test(t *testing.T) {
 	if c.CanSee != nil || c.CanNotSee != nil {
 		c.testRead(t)
 	}
+	if c.Values != nil {
+		c.testValues(t)
+	}
 }
 
 func (c *Case) loadDB() (map[string]json.RawMessage, error) {
-	data := make(map[string]json.RawMessage)
-	for dbKey, dbValue := range c.DB {
-		parts := strings.Split(dbKey, "/")
-		switch len(parts) {
-		case 1:
-			map1, ok := dbValue.(map[interface{}]interface{})
-			if !ok {
-				return nil, fmt.Errorf("invalid type in db key %s: %T", dbKey, dbValue)
-			}
-			for rawID, rawObject := range map1 {
-				id, ok := rawID.(int)
-				if !ok {
-					return nil, fmt.Errorf("invalid id type: got %T expected int", rawID)
-				}
-				field, ok := rawObject.(map[string]interface{})
-				if !ok {
-					return nil, fmt.Errorf("invalid object type: got %T, expected map[string]interface{}", rawObject)
-				}
-
-				for fieldName, fieldValue := range field {
-					fqfield := fmt.Sprintf("%s/%d/%s", dbKey, id, fieldName)
-					bs, err := json.Marshal(fieldValue)
-					if err != nil {
-						return nil, fmt.Errorf("creating test db. Key %s: %w", fqfield, err)
-					}
-					data[fqfield] = bs
-				}
-
-				idField := fmt.Sprintf("%s/%d/id", dbKey, id)
-				data[idField] = json.RawMessage(strconv.Itoa(id))
-			}
-
-		case 2:
-			field, ok := dbValue.(map[string]interface{})
-			if !ok {
-				return nil, fmt.Errorf("invalid object type: got %T, expected map[string]interface{}", dbValue)
-			}
-
-			for fieldName, fieldValue := range field {
-				fqfield := fmt.Sprintf("%s/%s/%s", parts[0], parts[1], fieldName)
-				bs, err := json.Marshal(fieldValue)
-				if err != nil {
-					return nil, fmt.Errorf("creating test db. Key %s: %w", fqfield, err)
-				}
-				data[fqfield] = bs
-			}
-
-			idField := fmt.Sprintf("%s/%s/id", parts[0], parts[1])
-			data[idField] = []byte(parts[1])
-
-		case 3:
-			bs, err := json.Marshal(dbValue)
-			if err != nil {
-				return nil, fmt.Errorf("creating test db. Key %s: %w", dbKey, err)
-			}
-			data[dbKey] = bs
-
-			idField := fmt.Sprintf("%s/%s/id", parts[0], parts[1])
-			data[idField] = []byte(parts[1])
-		default:
-			return nil, fmt.Errorf("invalid db key %s", dbKey)
-		}
-
+	data, err := datastore.ParseDump(c.DB)
+	if err != nil {
+		return nil, fmt.Errorf("parsing db: %w", err)
 	}
-
 	return data, nil
 }
 
@@ -218,6 +164,43 @@ func (c *Case) testRead(t *testing.T) {
 	}
 }
 
+func (c *Case) testValues(t *testing.T) {
+	p, err := c.service()
+	if err != nil {
+		t.Fatalf("Can not create permission service: %v", err)
+	}
+
+	data, err := c.loadDB()
+	if err != nil {
+		t.Fatalf("Can not load database: %v", err)
+	}
+
+	values := make(map[string]json.RawMessage, len(c.FQFields))
+	for _, f := range c.FQFields {
+		values[f] = data[f]
+	}
+
+	got, err := p.RestrictValues(context.Background(), c.userID, values)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %v", err)
+	}
+
+	if len(got) != len(c.Values) {
+		t.Errorf("Got %d values, expected %d", len(got), len(c.Values))
+	}
+
+	for k, v := range c.Values {
+		expect, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Invalid value for %s: %v", k, err)
+		}
+
+		if string(got[k]) != string(expect) {
+			t.Errorf("Got value `%s` for %s, expected `%s`", got[k], k, expect)
+		}
+	}
+}
+
 func (c *Case) readTestResult(t *testing.T, got map[string]bool, canSee, canNotSee []string) {
 	if len(got) != len(canSee) {
 		t.Errorf("Got %d fields, expected %d", len(got), len(canSee))
@@ -402,7 +385,8 @@ func sliceSubSet(slice []string, sub map[string]bool) []string {
 func expandFQID(fqid string) []string {
 	var fqfields []string
 	parts := strings.Split(fqid, "/")
-	for _, field := range collectionFields[parts[0]] {
+	fields, _ := models.Fields(parts[0])
+	for _, field := range fields {
 		fqfields = append(fqfields, fmt.Sprintf("%s/%s/%s", parts[0], parts[1], field))
 	}
 	return fqfields
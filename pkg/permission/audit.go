@@ -0,0 +1,396 @@
+package permission
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/metrics"
+)
+
+var (
+	auditDropped = metrics.NewCounter(
+		"permission_audit_dropped_total",
+		"Number of audit entries, that were dropped, because the buffer was full.",
+	)
+	auditErrors = metrics.NewCounter(
+		"permission_audit_errors_total",
+		"Number of audit entries, that could not be written.",
+	)
+)
+
+// AuditEntry is one decision of IsAllowed, IsAllowedWithReason or
+// IsAllowedPerPayload.
+type AuditEntry struct {
+	Time   time.Time
+	UserID int
+	Action string
+
+	// PayloadHash is the hex encoded sha256 hash of the json encoded payload.
+	// It is the payload list for IsAllowed and IsAllowedWithReason and a
+	// single payload for IsAllowedPerPayload.
+	PayloadHash string
+
+	// Result is one of allowed, denied or error.
+	Result string
+	Reason string
+
+	// Superadmin is true, if the decision was made without a check, because
+	// the user is a superadmin.
+	Superadmin bool
+}
+
+// payloadHash returns the hex encoded sha256 hash of the json encoded
+// payload.
+//
+// It has to be called before the entry is given to the sink, because the
+// caller can change the payload after the request.
+func payloadHash(payload interface{}) string {
+	bs, err := json.Marshal(payload)
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.Sum256(bs)
+	return hex.EncodeToString(hash[:])
+}
+
+// AuditSink receives all decisions about actions.
+//
+// Record is called while the request is handled, so it must not block.
+type AuditSink interface {
+	Record(entry AuditEntry)
+}
+
+// WithAudit sets a sink, that receives all decisions about actions.
+func WithAudit(sink AuditSink) Option {
+	return func(p *Permission) {
+		p.audit = sink
+	}
+}
+
+// record sends one decision to the audit sink, if there is one.
+func (ps *Permission) record(userID int, action string, payload interface{}, allowed bool, reason string, superadmin bool, err error) {
+	if ps.audit == nil {
+		return
+	}
+
+	result := "denied"
+	switch {
+	case err != nil:
+		// The error message can contain the payload, so only the type of the
+		// error is written.
+		result = "error"
+		var errPerm Error
+		if errors.As(classify(err), &errPerm) {
+			reason = errPerm.Type()
+		}
+	case allowed:
+		result = "allowed"
+	}
+
+	ps.audit.Record(AuditEntry{
+		Time:        time.Now(),
+		UserID:      userID,
+		Action:      action,
+		PayloadHash: payloadHash(payload),
+		Result:      result,
+		Reason:      reason,
+		Superadmin:  superadmin,
+	})
+}
+
+const (
+	defaultAuditBuffer   = 1024
+	defaultAuditMaxSize  = 100 << 20
+	defaultAuditMaxFiles = 10
+)
+
+// AuditFile is an AuditSink, that writes each entry as json object on one line
+// to a file. Decisions for superadmins are written to a separate file.
+//
+// The entries are written in the background. If the buffer is full, entries
+// are dropped, so the permission checks are never slowed down. This means,
+// that the audit log is not complete, if the writer can not keep up. The number
+// of dropped entries is exposed as metric and written to the file as an entry
+// with the result `dropped`, when the writer catches up.
+//
+// It has to be created with NewAuditFile and closed with Close.
+type AuditFile struct {
+	// dropped is the number of dropped entries, that are not yet written to
+	// the file. It has to be used with the atomic package and is the first
+	// field to be 64 bit aligned.
+	dropped int64
+
+	bufferSize int
+	maxSize    int64
+	maxFiles   int
+
+	decisions  *rotatingFile
+	superadmin *rotatingFile
+
+	mu      sync.RWMutex
+	closed  bool
+	entries chan AuditEntry
+	done    chan struct{}
+}
+
+// AuditOption is an optional argument for NewAuditFile.
+type AuditOption func(*AuditFile)
+
+// WithAuditBuffer sets the number of entries, that can wait to be written. The
+// default is 1024.
+func WithAuditBuffer(n int) AuditOption {
+	return func(a *AuditFile) {
+		a.bufferSize = n
+	}
+}
+
+// WithAuditRotation sets the size in bytes after that a file is rotated and
+// the number of rotated files that are kept. The rotated files get the
+// suffixes .1, .2 and so on. The default is 100 MiB and 10 files.
+func WithAuditRotation(maxSize int64, maxFiles int) AuditOption {
+	return func(a *AuditFile) {
+		a.maxSize = maxSize
+		a.maxFiles = maxFiles
+	}
+}
+
+// NewAuditFile opens the files and starts the background writer.
+//
+// The files are created if they do not exist. Existing files are appended.
+func NewAuditFile(path, superadminPath string, options ...AuditOption) (*AuditFile, error) {
+	a := &AuditFile{
+		bufferSize: defaultAuditBuffer,
+		maxSize:    defaultAuditMaxSize,
+		maxFiles:   defaultAuditMaxFiles,
+		done:       make(chan struct{}),
+	}
+
+	for _, o := range options {
+		o(a)
+	}
+
+	decisions, err := openRotatingFile(path, a.maxSize, a.maxFiles)
+	if err != nil {
+		return nil, fmt.Errorf("open audit file: %w", err)
+	}
+
+	superadmin, err := openRotatingFile(superadminPath, a.maxSize, a.maxFiles)
+	if err != nil {
+		decisions.close()
+		return nil, fmt.Errorf("open superadmin audit file: %w", err)
+	}
+
+	a.decisions = decisions
+	a.superadmin = superadmin
+	a.entries = make(chan AuditEntry, a.bufferSize)
+
+	go a.write()
+	return a, nil
+}
+
+// Record implements the AuditSink interface. It does not block.
+func (a *AuditFile) Record(entry AuditEntry) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		auditDropped.Inc()
+		return
+	}
+
+	select {
+	case a.entries <- entry:
+	default:
+		auditDropped.Inc()
+		atomic.AddInt64(&a.dropped, 1)
+	}
+}
+
+// Close writes all waiting entries and closes the files.
+func (a *AuditFile) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.entries)
+	a.mu.Unlock()
+
+	<-a.done
+
+	err1 := a.decisions.close()
+	err2 := a.superadmin.close()
+	if err1 != nil {
+		return fmt.Errorf("closing audit file: %w", err1)
+	}
+	if err2 != nil {
+		return fmt.Errorf("closing superadmin audit file: %w", err2)
+	}
+	return nil
+}
+
+// write is the background writer. It runs until Close is called.
+func (a *AuditFile) write() {
+	defer close(a.done)
+	defer a.writeDropped()
+
+	for entry := range a.entries {
+		a.writeDropped()
+
+		line := struct {
+			Time        string `json:"time"`
+			UserID      int    `json:"user_id"`
+			Action      string `json:"action"`
+			PayloadHash string `json:"payload_sha256"`
+			Result      string `json:"result"`
+			Reason      string `json:"reason,omitempty"`
+			Superadmin  bool   `json:"superadmin,omitempty"`
+		}{
+			Time:        entry.Time.UTC().Format(time.RFC3339Nano),
+			UserID:      entry.UserID,
+			Action:      entry.Action,
+			PayloadHash: entry.PayloadHash,
+			Result:      entry.Result,
+			Reason:      entry.Reason,
+			Superadmin:  entry.Superadmin,
+		}
+
+		bs, err := json.Marshal(line)
+		if err != nil {
+			auditErrors.Inc()
+			continue
+		}
+
+		file := a.decisions
+		if entry.Superadmin {
+			file = a.superadmin
+		}
+
+		if err := file.write(append(bs, '\n')); err != nil {
+			auditErrors.Inc()
+		}
+	}
+}
+
+// writeDropped writes the number of dropped entries to the file, if entries
+// were dropped since the last call.
+func (a *AuditFile) writeDropped() {
+	n := atomic.SwapInt64(&a.dropped, 0)
+	if n == 0 {
+		return
+	}
+
+	line := struct {
+		Time    string `json:"time"`
+		Result  string `json:"result"`
+		Dropped int64  `json:"dropped"`
+	}{
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Result:  "dropped",
+		Dropped: n,
+	}
+
+	bs, err := json.Marshal(line)
+	if err != nil {
+		auditErrors.Inc()
+		return
+	}
+
+	if err := a.decisions.write(append(bs, '\n')); err != nil {
+		auditErrors.Inc()
+	}
+}
+
+// rotatingFile is an append only file, that is renamed, when it gets to big.
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	f    *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", r.path, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("reading size of %s: %w", r.path, err)
+	}
+
+	r.f = f
+	r.size = info.Size()
+	return nil
+}
+
+// write appends one line to the file. If the line does not fit in the file,
+// the file is rotated first.
+func (r *rotatingFile) write(line []byte) error {
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(line)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return fmt.Errorf("rotating %s: %w", r.path, err)
+		}
+	}
+
+	n, err := r.f.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("writing to %s: %w", r.path, err)
+	}
+	return nil
+}
+
+// rotate renames path to path.1, path.1 to path.2 and so on and opens a new
+// file. The oldest file is removed.
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+
+	if r.maxFiles > 0 {
+		if err := os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxFiles)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing oldest file: %w", err)
+		}
+
+		for i := r.maxFiles - 1; i > 0; i-- {
+			err := os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("renaming file %d: %w", i, err)
+			}
+		}
+
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return fmt.Errorf("renaming current file: %w", err)
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return fmt.Errorf("removing current file: %w", err)
+	}
+
+	return r.open()
+}
+
+func (r *rotatingFile) close() error {
+	return r.f.Close()
+}
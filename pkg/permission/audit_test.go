@@ -0,0 +1,140 @@
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type auditMock struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func (a *auditMock) Record(entry AuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+func TestAuditRecord(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids":                  []byte("[2]"),
+		"group/2/permissions":                  []byte(`["motion.can_see"]`),
+		"user/2/organisation_management_level": []byte(`"superadmin"`),
+	}}
+	sink := new(auditMock)
+	p := New(dp, WithAudit(sink))
+
+	payload := []map[string]json.RawMessage{{"meeting_id": []byte("1")}}
+	if _, err := p.IsAllowed(context.Background(), "motion.create", 1, payload); err != nil {
+		t.Fatalf("IsAllowed returned unexpected error: %v", err)
+	}
+	if _, err := p.IsAllowedPerPayload(context.Background(), "motion.create", 2, payload); err != nil {
+		t.Fatalf("IsAllowedPerPayload returned unexpected error: %v", err)
+	}
+
+	expectHash := payloadHash(payload)
+	payload[0]["meeting_id"] = []byte("2")
+
+	if len(sink.entries) != 2 {
+		t.Fatalf("Got %d entries, expected 2", len(sink.entries))
+	}
+
+	denied := sink.entries[0]
+	if denied.UserID != 1 || denied.Action != "motion.create" || denied.Result != "denied" || denied.Superadmin {
+		t.Errorf("Got entry %+v, expected a denied entry for user 1", denied)
+	}
+	if denied.PayloadHash != expectHash {
+		t.Errorf("Got payload hash %s, expected the hash of the payload at call time %s", denied.PayloadHash, expectHash)
+	}
+	if !strings.Contains(denied.Reason, "motion.can_create") {
+		t.Errorf("Reason `%s` does not contain the missing permission", denied.Reason)
+	}
+
+	if bypass := sink.entries[1]; bypass.UserID != 2 || bypass.Result != "allowed" || !bypass.Superadmin {
+		t.Errorf("Got entry %+v, expected an allowed superadmin entry for user 2", bypass)
+	}
+
+	invalid := []map[string]json.RawMessage{{"meeting_id": []byte(`"secret"`)}}
+	if _, err := p.IsAllowed(context.Background(), "motion.create", 1, invalid); err == nil {
+		t.Fatalf("IsAllowed returned no error for an invalid payload")
+	}
+
+	if errEntry := sink.entries[2]; errEntry.Result != "error" || errEntry.Reason != ErrInvalid {
+		t.Errorf("Got entry %+v, expected an error entry with reason %s", errEntry, ErrInvalid)
+	}
+}
+
+func TestAuditFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	superadminPath := filepath.Join(dir, "audit_superadmin.jsonl")
+
+	a, err := NewAuditFile(path, superadminPath, WithAuditRotation(400, 2))
+	if err != nil {
+		t.Fatalf("NewAuditFile returned unexpected error: %v", err)
+	}
+
+	// Simulate dropped entries. They are written before the next entry.
+	atomic.AddInt64(&a.dropped, 3)
+
+	for i := 0; i < 5; i++ {
+		a.Record(AuditEntry{UserID: 1, Action: "motion.create", PayloadHash: payloadHash(map[string]int{"id": i}), Result: "denied", Reason: "no"})
+	}
+	a.Record(AuditEntry{UserID: 2, Action: "motion.delete", Result: "allowed", Superadmin: true})
+
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close returned unexpected error: %v", err)
+	}
+
+	var lines []string
+	for _, name := range []string{path + ".2", path + ".1", path} {
+		bs, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Reading %s: %v", name, err)
+		}
+		if len(bs) > 400 {
+			t.Errorf("File %s has %d bytes, expected at most 400", name, len(bs))
+		}
+		lines = append(lines, strings.Split(strings.TrimSpace(string(bs)), "\n")...)
+	}
+
+	if len(lines) != 6 {
+		t.Fatalf("Got %d lines in the rotated files, expected 6", len(lines))
+	}
+
+	if !strings.Contains(lines[0], `"result":"dropped","dropped":3`) {
+		t.Errorf("Got first line %s, expected the dropped entries", lines[0])
+	}
+	lines = lines[1:]
+
+	var entry struct {
+		UserID      int    `json:"user_id"`
+		Action      string `json:"action"`
+		PayloadHash string `json:"payload_sha256"`
+		Result      string `json:"result"`
+		Reason      string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Decoding line `%s`: %v", lines[0], err)
+	}
+
+	expectHash := payloadHash(map[string]int{"id": 0})
+	if entry.UserID != 1 || entry.Action != "motion.create" || entry.PayloadHash != expectHash || entry.Result != "denied" || entry.Reason != "no" {
+		t.Errorf("Got first line %s", lines[0])
+	}
+
+	bs, err := os.ReadFile(superadminPath)
+	if err != nil {
+		t.Fatalf("Reading superadmin file: %v", err)
+	}
+	if !strings.Contains(string(bs), `"action":"motion.delete"`) || strings.Count(string(bs), "\n") != 1 {
+		t.Errorf("Got superadmin file `%s`, expected one line for motion.delete", bs)
+	}
+}
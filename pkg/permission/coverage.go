@@ -0,0 +1,100 @@
+package permission
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/models"
+)
+
+// Coverage tells, which actions and collections are not handled by the
+// permission service.
+//
+// It compares the registered handlers with the models and the backend actions
+// that are embedded into the service.
+type Coverage struct {
+	// MissingActions are backend actions without a handler.
+	MissingActions []string
+
+	// MissingCollections are collections without a restricter.
+	MissingCollections []string
+
+	// UnknownActions are handlers for actions, that the backend does not know.
+	UnknownActions []string
+
+	// UnknownCollections are restricters for collections, that do not exist.
+	UnknownCollections []string
+}
+
+// Coverage returns the actions and collections that are not handled.
+func (ps *Permission) Coverage() Coverage {
+	collections, actions := ps.AllRoutes()
+
+	var c Coverage
+	c.MissingActions, c.UnknownActions = difference(models.Actions(), actions)
+	c.MissingCollections, c.UnknownCollections = difference(models.Collections(), collections)
+	return c
+}
+
+// Complete returns true, if there is no missing action or collection.
+func (c Coverage) Complete() bool {
+	return len(c.MissingActions) == 0 && len(c.MissingCollections) == 0
+}
+
+// WriteReport writes a human readable report of the coverage to w.
+//
+// Nothing is written, if there is nothing missing and nothing unknown.
+func (c Coverage) WriteReport(w io.Writer) error {
+	for _, section := range []struct {
+		title string
+		names []string
+	}{
+		{"Missing Actions", c.MissingActions},
+		{"Unknown Actions", c.UnknownActions},
+		{"Missing Collections", c.MissingCollections},
+		{"Unknown Collections", c.UnknownCollections},
+	} {
+		if len(section.names) == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "%s:\n", section.title); err != nil {
+			return fmt.Errorf("writing title: %w", err)
+		}
+
+		for _, name := range section.names {
+			if _, err := fmt.Fprintf(w, "* %s\n", name); err != nil {
+				return fmt.Errorf("writing %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// difference returns the sorted values that are only in expected and the
+// sorted values that are only in got.
+func difference(expected, got []string) (missing, unknown []string) {
+	gotSet := make(map[string]bool, len(got))
+	for _, v := range got {
+		gotSet[v] = true
+	}
+
+	expectedSet := make(map[string]bool, len(expected))
+	for _, v := range expected {
+		expectedSet[v] = true
+		if !gotSet[v] {
+			missing = append(missing, v)
+		}
+	}
+
+	for _, v := range got {
+		if !expectedSet[v] {
+			unknown = append(unknown, v)
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unknown)
+	return missing, unknown
+}
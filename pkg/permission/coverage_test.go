@@ -0,0 +1,78 @@
+package permission
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader(`
+version: 1
+actions:
+  topic.create: agenda_item.can_manage
+  topic.dance: agenda_item.can_manage
+`))
+	if err != nil {
+		t.Fatalf("LoadRules returned unexpected error: %v", err)
+	}
+
+	coverage := New(nil, WithRules(rules)).Coverage()
+
+	if coverage.Complete() {
+		t.Errorf("Coverage is complete, expected missing routes")
+	}
+
+	for _, tt := range []struct {
+		name   string
+		list   []string
+		value  string
+		expect bool
+	}{
+		{"missing action", coverage.MissingActions, "tag.create", true},
+		{"handled action", coverage.MissingActions, "topic.create", false},
+		{"unknown action", coverage.UnknownActions, "topic.dance", true},
+		{"missing collection", coverage.MissingCollections, "tag", true},
+		{"handled collection", coverage.MissingCollections, "motion", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var found bool
+			for _, v := range tt.list {
+				if v == tt.value {
+					found = true
+				}
+			}
+
+			if found != tt.expect {
+				t.Errorf("Found %s: %t, expected %t", tt.value, found, tt.expect)
+			}
+		})
+	}
+
+	var report strings.Builder
+	if err := coverage.WriteReport(&report); err != nil {
+		t.Fatalf("WriteReport returned unexpected error: %v", err)
+	}
+
+	if !strings.Contains(report.String(), "Missing Actions:\n") || !strings.Contains(report.String(), "* tag.create\n") {
+		t.Errorf("Report does not contain the missing action:\n%s", report.String())
+	}
+}
+
+func TestCoverageDefault(t *testing.T) {
+	coverage := New(nil).Coverage()
+
+	var report strings.Builder
+	if err := coverage.WriteReport(&report); err != nil {
+		t.Fatalf("WriteReport returned unexpected error: %v", err)
+	}
+
+	expect := `Missing Actions:
+* assignment_candidate.sort
+* motion.create_forwarded
+* option.update
+* resource.upload
+`
+	if report.String() != expect {
+		t.Errorf("Got report:\n%s\nexpected:\n%s", report.String(), expect)
+	}
+}
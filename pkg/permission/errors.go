@@ -0,0 +1,75 @@
+package permission
+
+import (
+	"errors"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
+	"github.com/OpenSlides/openslides-permission-service/internal/perm"
+)
+
+// Types of errors returned by the Permission methods.
+const (
+	// ErrInvalid means, that the request or a payload is invalid.
+	ErrInvalid = "invalid"
+
+	// ErrNotFound means, that an action or collection is unknown.
+	ErrNotFound = "not_found"
+
+	// ErrDatastore means, that the data could not be fetched from the
+	// datastore.
+	ErrDatastore = "datastore"
+
+	// ErrInternal is used for all other errors. It is a bug in the permission
+	// service.
+	ErrInternal = "internal"
+)
+
+// Error is the error returned by the methods of Permission.
+//
+// The method Type() returns one of the Err... constants, so the caller can
+// decide how to handle the error.
+type Error struct {
+	typ string
+	err error
+}
+
+func (e Error) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e Error) Unwrap() error {
+	return e.err
+}
+
+// Type returns the type of the error.
+func (e Error) Type() string {
+	return e.typ
+}
+
+// classify wraps an error in an Error with the fitting type.
+//
+// Errors, that are already an Error keep their type.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var permErr Error
+	if errors.As(err, &permErr) {
+		return Error{typ: permErr.typ, err: err}
+	}
+
+	var invalidPayload perm.InvalidPayloadError
+	var invalidKey dataprovider.InvalidKeyError
+	var doesNotExist dataprovider.DoesNotExistError
+	var external dataprovider.ExternalError
+	switch {
+	case errors.As(err, &external):
+		return Error{typ: ErrDatastore, err: err}
+	case errors.As(err, &invalidPayload), errors.As(err, &invalidKey), errors.As(err, &doesNotExist):
+		return Error{typ: ErrInvalid, err: err}
+	default:
+		return Error{typ: ErrInternal, err: err}
+	}
+}
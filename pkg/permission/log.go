@@ -0,0 +1,162 @@
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/perm"
+)
+
+// Level is the severity of a log entry.
+type Level int32
+
+// The log levels from the most verbose to the least verbose.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	name, ok := levelNames[l]
+	if !ok {
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+	return name
+}
+
+// ParseLevel returns the level for one of the names debug, info, warn or
+// error.
+func ParseLevel(name string) (Level, error) {
+	for level, n := range levelNames {
+		if n == strings.ToLower(name) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level `%s`", name)
+}
+
+// Logger receives log entries from the permission service.
+//
+// The reasons, why something is not allowed, are logged with the level debug.
+// Each entry has the fields user_id, meeting_id, reason and action or
+// collection.
+type Logger interface {
+	// Enabled tells, if entries with the level should be logged. It is called
+	// before an entry is created, so expensive entries can be skipped.
+	Enabled(level Level) bool
+
+	Log(level Level, msg string, fields map[string]interface{})
+}
+
+// WithLogger sets a logger for the permission service.
+//
+// If this option is not used, nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(p *Permission) {
+		p.logger = logger
+	}
+}
+
+// JSONLogger writes each log entry as json object on one line.
+//
+// The level can be changed at runtime. It has to be created with
+// NewJSONLogger.
+type JSONLogger struct {
+	level int32
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLogger creates a JSONLogger, that writes all entries with the given
+// level or above to w.
+func NewJSONLogger(w io.Writer, level Level) *JSONLogger {
+	return &JSONLogger{w: w, level: int32(level)}
+}
+
+// Level returns the current level.
+func (l *JSONLogger) Level() Level {
+	return Level(atomic.LoadInt32(&l.level))
+}
+
+// SetLevel changes the level of the logger.
+func (l *JSONLogger) SetLevel(level Level) {
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+// Enabled returns true, if the level is the current level or above.
+func (l *JSONLogger) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
+// Log writes one entry. The fields time, level and msg are added to the given
+// fields.
+func (l *JSONLogger) Log(level Level, msg string, fields map[string]interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	entry := make(map[string]interface{}, len(fields)+3)
+	for k, v := range fields {
+		entry[k] = v
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+
+	bs, err := json.Marshal(entry)
+	if err != nil {
+		bs = []byte(fmt.Sprintf(`{"level":"error","msg":"Can not encode log entry: %q"}`, err.Error()))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(bs, '\n'))
+}
+
+// denialLogger sends the reasons from perm.LogNotAllowedf to a Logger.
+type denialLogger struct {
+	logger Logger
+	fields map[string]interface{}
+}
+
+func (l denialLogger) LogDenial(meetingID int, reason string) {
+	fields := make(map[string]interface{}, len(l.fields)+2)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fields["meeting_id"] = meetingID
+	fields["reason"] = reason
+	l.logger.Log(LevelDebug, "not allowed", fields)
+}
+
+// withDenialLog returns a context, that logs all reasons given to
+// perm.LogNotAllowedf.
+//
+// The kind is "action" or "collection" and name is the name of the action or
+// collection. If debug logging is disabled, ctx is returned unchanged.
+func (ps *Permission) withDenialLog(ctx context.Context, userID int, kind, name string) context.Context {
+	if ps.logger == nil || !ps.logger.Enabled(LevelDebug) {
+		return ctx
+	}
+
+	return perm.WithDenialLogger(ctx, denialLogger{
+		logger: ps.logger,
+		fields: map[string]interface{}{"user_id": userID, kind: name},
+	})
+}
@@ -0,0 +1,141 @@
+package permission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDenialLog(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids": []byte("[2]"),
+		"group/2/permissions": []byte(`["motion.can_see"]`),
+	}}
+	buf := new(bytes.Buffer)
+	p := New(dp, WithLogger(NewJSONLogger(buf, LevelDebug)))
+
+	payload := []map[string]json.RawMessage{{"meeting_id": []byte("1"), "title": []byte(`"foo"`)}}
+	if _, err := p.IsAllowed(context.Background(), "motion.create", 1, payload); err != nil {
+		t.Fatalf("IsAllowed returned unexpected error: %v", err)
+	}
+
+	var entry struct {
+		Level     string `json:"level"`
+		Msg       string `json:"msg"`
+		UserID    int    `json:"user_id"`
+		MeetingID int    `json:"meeting_id"`
+		Action    string `json:"action"`
+		Reason    string `json:"reason"`
+	}
+	line := strings.SplitN(buf.String(), "\n", 2)[0]
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("Can not decode log entry `%s`: %v", line, err)
+	}
+
+	if entry.Level != "debug" || entry.UserID != 1 || entry.MeetingID != 1 || entry.Action != "motion.create" {
+		t.Errorf("Got log entry %s, expected debug entry for user 1, meeting 1 and action motion.create", line)
+	}
+
+	if !strings.Contains(entry.Reason, "motion.can_create") {
+		t.Errorf("Reason `%s` does not contain the missing permission", entry.Reason)
+	}
+}
+
+func TestDenialLogLevel(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids": []byte("[2]"),
+		"group/2/permissions": []byte(`[]`),
+	}}
+	buf := new(bytes.Buffer)
+	logger := NewJSONLogger(buf, LevelInfo)
+	p := New(dp, WithLogger(logger))
+
+	payload := []map[string]json.RawMessage{{"meeting_id": []byte("1")}}
+	if _, err := p.IsAllowed(context.Background(), "motion.create", 1, payload); err != nil {
+		t.Fatalf("IsAllowed returned unexpected error: %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Got log output `%s` with level info, expected nothing", buf.String())
+	}
+
+	logger.SetLevel(LevelDebug)
+	if _, err := p.IsAllowed(context.Background(), "motion.create", 1, payload); err != nil {
+		t.Fatalf("IsAllowed returned unexpected error: %v", err)
+	}
+
+	if buf.Len() == 0 {
+		t.Errorf("Got no log output after switching to level debug")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		got, err := ParseLevel(level.String())
+		if err != nil {
+			t.Errorf("ParseLevel(%s) returned unexpected error: %v", level, err)
+		}
+		if got != level {
+			t.Errorf("ParseLevel(%s) returned %s", level, got)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("ParseLevel(verbose) returned no error")
+	}
+}
+
+func TestDenialLogMeetings(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids":                     []byte("[1]"),
+		"user/1/group_$2_ids":                     []byte("[2]"),
+		"group/1/permissions":                     []byte(`[]`),
+		"group/2/permissions":                     []byte(`[]`),
+		"agenda_item/1/meeting_id":                []byte("1"),
+		"agenda_item/2/meeting_id":                []byte("2"),
+		"poll/5/meeting_id":                       []byte("2"),
+		"poll/5/state":                            []byte(`"created"`),
+		"meeting/2/user_ids":                      []byte("[1]"),
+		"organisation/1/enable_electronic_voting": []byte("true"),
+	}}
+	buf := new(bytes.Buffer)
+	p := New(dp, WithLogger(NewJSONLogger(buf, LevelDebug)))
+
+	if _, err := p.RestrictFQFields(context.Background(), 1, []string{"agenda_item/1/id", "agenda_item/2/id"}); err != nil {
+		t.Fatalf("RestrictFQFields returned unexpected error: %v", err)
+	}
+
+	payload := []map[string]json.RawMessage{{"id": []byte("5"), "user_id": []byte("1")}}
+	if _, err := p.IsAllowed(context.Background(), "poll.vote", 1, payload); err != nil {
+		t.Fatalf("IsAllowed returned unexpected error: %v", err)
+	}
+
+	got := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			MeetingID int    `json:"meeting_id"`
+			Reason    string `json:"reason"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Can not decode log entry `%s`: %v", line, err)
+		}
+		got[entry.Reason] = entry.MeetingID
+	}
+
+	for reason, meetingID := range map[string]int{
+		"User 1 does not have the permission agenda_item.can_see to see agenda_item/1": 1,
+		"User 1 does not have the permission agenda_item.can_see to see agenda_item/2": 2,
+		"Poll 5 is not started": 2,
+	} {
+		gotID, ok := got[reason]
+		if !ok {
+			t.Errorf("Reason `%s` was not logged. Got: %v", reason, got)
+			continue
+		}
+		if gotID != meetingID {
+			t.Errorf("Reason `%s` was logged with meeting %d, expected %d", reason, gotID, meetingID)
+		}
+	}
+}
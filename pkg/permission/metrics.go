@@ -0,0 +1,78 @@
+package permission
+
+import (
+	"time"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/metrics"
+	"github.com/OpenSlides/openslides-permission-service/internal/perm"
+)
+
+var (
+	actionChecks = metrics.NewCounter(
+		"permission_action_checks_total",
+		"Number of checked actions by result (allowed, denied or error).",
+		"action", "result",
+	)
+	actionDuration = metrics.NewHistogram(
+		"permission_action_duration_seconds",
+		"Time to check all payloads of an action.",
+		metrics.DefaultBuckets,
+		"action",
+	)
+	collectionChecks = metrics.NewCounter(
+		"permission_collection_checks_total",
+		"Number of restricted collections in calls to restrict fqfields.",
+		"collection",
+	)
+	collectionFields = metrics.NewCounter(
+		"permission_collection_fields_total",
+		"Number of restricted fields by result (allowed or denied).",
+		"collection", "result",
+	)
+	collectionDuration = metrics.NewHistogram(
+		"permission_collection_duration_seconds",
+		"Time to restrict the fields of one collection.",
+		metrics.DefaultBuckets,
+		"collection",
+	)
+)
+
+// observeAction records the metrics for one check of an action.
+//
+// Unknown actions are recorded with the name `unknown`, so a client can not
+// create an unlimited number of metrics.
+func (ps *Permission) observeAction(action string, start time.Time, allowed bool, err error) {
+	if _, ok := ps.hs.actions[action]; !ok {
+		action = "unknown"
+	}
+
+	result := "denied"
+	switch {
+	case err != nil:
+		result = "error"
+	case allowed:
+		result = "allowed"
+	}
+
+	actionChecks.Inc(action, result)
+	actionDuration.Since(start, action)
+}
+
+// observeCollection records the metrics for the restriction of one collection.
+func (ps *Permission) observeCollection(name string, start time.Time, fqfields []perm.FQField, allowed map[string]bool) {
+	if _, ok := ps.hs.collections[name]; !ok {
+		name = "unknown"
+	}
+
+	var allowedCount int
+	for _, fqfield := range fqfields {
+		if allowed[fqfield.String()] {
+			allowedCount++
+		}
+	}
+
+	collectionChecks.Inc(name)
+	collectionFields.Add(float64(allowedCount), name, "allowed")
+	collectionFields.Add(float64(len(fqfields)-allowedCount), name, "denied")
+	collectionDuration.Since(start, name)
+}
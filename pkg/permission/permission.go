@@ -8,8 +8,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
+	"github.com/OpenSlides/openslides-permission-service/internal/models"
 	"github.com/OpenSlides/openslides-permission-service/internal/perm"
 )
 
@@ -18,73 +23,271 @@ import (
 type Permission struct {
 	hs *handlerStore
 
-	dp dataprovider.DataProvider
+	dp          dataprovider.DataProvider
+	rules       *Rules
+	parallelism int
+	logger      Logger
+	audit       AuditSink
 }
 
 // New returns a new permission service.
 //
 // It requires a permission.DataProvider to access the database.
-func New(dp DataProvider) *Permission {
+func New(dp DataProvider, options ...Option) *Permission {
 	p := &Permission{
-		hs: newHandlerStore(),
-		dp: dataprovider.DataProvider{External: dp},
+		hs:          newHandlerStore(),
+		dp:          dataprovider.DataProvider{External: dp},
+		parallelism: defaultParallelism,
+	}
+
+	for _, o := range options {
+		o(p)
+	}
+
+	if p.rules == nil {
+		p.rules = DefaultRules()
 	}
 
 	for _, con := range openSlidesCollections(p.dp) {
 		con.Connect(p.hs)
 	}
 
+	for _, con := range p.rules.connecters(p.dp) {
+		con.Connect(p.hs)
+	}
+
 	return p
 }
 
+// Option is an optional argument for New().
+type Option func(*Permission)
+
+// WithParallelism sets the number of payloads or collections, that are checked
+// at the same time.
+//
+// The value 1 checks everything one after another.
+func WithParallelism(n int) Option {
+	return func(p *Permission) {
+		if n < 1 {
+			n = 1
+		}
+		p.parallelism = n
+	}
+}
+
+// WithRules sets the rules for simple actions and collections.
+//
+// If this option is not used, the default rules are used.
+func WithRules(rules *Rules) Option {
+	return func(p *Permission) {
+		p.rules = rules
+	}
+}
+
 // IsAllowed returns true, if the user can access the given action.
 //
 // One call to IsAllowed() handels a list of requests to this action. For each
 // entry in the payloadList the method checks, if the user is allowed to use the
 // action. The method returns true, if the user can the action for all of the
 // given payloads.
+//
+// Each key is only requested once from the DataProvider for one call.
 func (ps *Permission) IsAllowed(ctx context.Context, action string, userID int, payloadList []map[string]json.RawMessage) (bool, error) {
-	superadmin, err := ps.dp.IsSuperadmin(ctx, userID)
+	start := time.Now()
+	allowed, reason, superadmin, err := ps.isAllowed(ctx, action, userID, payloadList)
+	ps.observeAction(action, start, allowed, err)
+	ps.record(userID, action, payloadList, allowed, reason, superadmin, err)
+	return allowed, classify(err)
+}
+
+// IsAllowedWithReason is like IsAllowed but also returns the reason, why the
+// user is not allowed.
+//
+// The reason is an empty string, if the user is allowed.
+func (ps *Permission) IsAllowedWithReason(ctx context.Context, action string, userID int, payloadList []map[string]json.RawMessage) (bool, string, error) {
+	start := time.Now()
+	allowed, reason, superadmin, err := ps.isAllowed(ctx, action, userID, payloadList)
+	ps.observeAction(action, start, allowed, err)
+	ps.record(userID, action, payloadList, allowed, reason, superadmin, err)
+	return allowed, reason, classify(err)
+}
+
+// isAllowed checks all payloads. The third return value is true, if the user
+// is a superadmin and the payloads were not checked.
+func (ps *Permission) isAllowed(ctx context.Context, action string, userID int, payloadList []map[string]json.RawMessage) (bool, string, bool, error) {
+	ctx = dataprovider.WithCache(ctx)
+
+	handler, superadmin, err := ps.actionHandler(ctx, action, userID)
 	if err != nil {
-		return false, fmt.Errorf("checking for superadmin: %w", err)
+		return false, "", false, err
 	}
 	if superadmin {
-		return true, nil
+		return true, "", true, nil
 	}
 
-	// TODO: after all handlers are implemented. Move this code above the superadmin check.
-	handler, ok := ps.hs.actions[action]
-	if !ok {
-		return false, fmt.Errorf("unknown action: `%s`", action)
+	// The payloads are checked concurrently. Payloads after the first one,
+	// that is not allowed, do not have to be checked. To get the same result
+	// on each call, the results are scanned in order afterwards.
+	results := make([]PayloadResult, len(payloadList))
+	failed := newMinIndex()
+	err = parallel(ctx, ps.parallelism, len(payloadList), func(i int) {
+		if !failed.before(i) {
+			return
+		}
+
+		allowed, reason, err := checkPayload(ps.withDenialLog(ctx, userID, "action", action), handler, userID, payloadList[i])
+		results[i] = PayloadResult{Allowed: allowed, Reason: reason, Err: err}
+		if !allowed {
+			failed.set(i)
+		}
+	})
+	if err != nil {
+		return false, "", false, fmt.Errorf("checking payloads: %w", err)
 	}
 
-	for i, payload := range payloadList {
-		allowed, err := handler.IsAllowed(ctx, userID, payload)
-		if err != nil {
-			bs, jsonErr := json.Marshal(payload)
+	for i, result := range results {
+		if result.Err != nil {
+			bs, jsonErr := json.Marshal(payloadList[i])
 			if jsonErr != nil {
 				bs = []byte("[payload can not be encoded]")
 			}
-			return false, fmt.Errorf("action: %s, payload-index %d: `%s`: %w", action, i, bs, err)
+			return false, "", false, fmt.Errorf("action: %s, payload-index %d: `%s`: %w", action, i, bs, result.Err)
 		}
-		if !allowed {
-			return false, nil
+		if !result.Allowed {
+			return false, fmt.Sprintf("payload-index %d: %s", i, result.Reason), false, nil
 		}
 	}
 
-	return true, nil
+	return true, "", false, nil
+}
+
+// PayloadResult is the result for one payload of an action.
+type PayloadResult struct {
+	Allowed bool
+
+	// Reason tells, why the payload is not allowed. It is empty, if the
+	// payload is allowed or if an error happened.
+	Reason string
+
+	// Err is the error, that happened while checking the payload. It is an
+	// Error object.
+	Err error
+}
+
+// IsAllowedPerPayload is like IsAllowed but returns one result for each
+// payload.
+//
+// All payloads are checked, also if one of them is not allowed or returns an
+// error. The returned error is only set, if no payload could be checked, for
+// example if the action is unknown.
+func (ps *Permission) IsAllowedPerPayload(ctx context.Context, action string, userID int, payloadList []map[string]json.RawMessage) ([]PayloadResult, error) {
+	start := time.Now()
+	results, superadmin, err := ps.isAllowedPerPayload(ctx, action, userID, payloadList)
+
+	allowed := true
+	observedErr := err
+	for _, r := range results {
+		if !r.Allowed {
+			allowed = false
+		}
+		if r.Err != nil && observedErr == nil {
+			observedErr = r.Err
+		}
+	}
+	ps.observeAction(action, start, allowed, observedErr)
+
+	if err != nil {
+		ps.record(userID, action, payloadList, false, "", false, err)
+	}
+	for i, r := range results {
+		ps.record(userID, action, payloadList[i], r.Allowed, r.Reason, superadmin, r.Err)
+	}
+
+	return results, classify(err)
+}
+
+// isAllowedPerPayload checks each payload. The second return value is true, if
+// the user is a superadmin and the payloads were not checked.
+func (ps *Permission) isAllowedPerPayload(ctx context.Context, action string, userID int, payloadList []map[string]json.RawMessage) ([]PayloadResult, bool, error) {
+	ctx = dataprovider.WithCache(ctx)
+
+	handler, superadmin, err := ps.actionHandler(ctx, action, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	results := make([]PayloadResult, len(payloadList))
+	if superadmin {
+		for i := range results {
+			results[i].Allowed = true
+		}
+		return results, true, nil
+	}
+
+	err = parallel(ctx, ps.parallelism, len(payloadList), func(i int) {
+		allowed, reason, err := checkPayload(ps.withDenialLog(ctx, userID, "action", action), handler, userID, payloadList[i])
+		if err != nil {
+			results[i].Err = classify(fmt.Errorf("action: %s: %w", action, err))
+			return
+		}
+		results[i].Allowed = allowed
+		results[i].Reason = reason
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("checking payloads: %w", err)
+	}
+	return results, false, nil
+}
+
+// actionHandler returns the handler for an action.
+//
+// If the user is a superadmin, the second return value is true. In this case
+// the handler can be nil.
+func (ps *Permission) actionHandler(ctx context.Context, action string, userID int) (perm.Action, bool, error) {
+	superadmin, err := ps.dp.IsSuperadmin(ctx, userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("checking for superadmin: %w", err)
+	}
+	if superadmin {
+		return nil, true, nil
+	}
+
+	// TODO: after all handlers are implemented. Move this code above the superadmin check.
+	handler, ok := ps.hs.actions[action]
+	if !ok {
+		return nil, false, Error{typ: ErrNotFound, err: fmt.Errorf("unknown action: `%s`", action)}
+	}
+	return handler, false, nil
+}
+
+// checkPayload calls the handler for one payload. If the payload is not
+// allowed, the reason is returned.
+func checkPayload(ctx context.Context, handler perm.Action, userID int, payload map[string]json.RawMessage) (bool, string, error) {
+	payloadCtx, explanation := perm.WithExplanation(ctx)
+	allowed, err := handler.IsAllowed(payloadCtx, userID, payload)
+	if err != nil {
+		return false, "", err
+	}
+
+	if !allowed {
+		return false, reasonText(explanation.Reasons()), nil
+	}
+	return true, "", nil
 }
 
 // superadminFields handles fields that the superadmin is not allowed to see.
 //
 // Returns true, if the normal normal restricters should be skiped.
-func superadminFields(result map[string]bool, collection string, fqfields []perm.FQField) (skip bool) {
+func superadminFields(result map[string]bool, reasons map[string]string, collection string, fqfields []perm.FQField) (skip bool) {
 	if collection == "personal_note" {
 		return false
 	}
 
 	for _, k := range fqfields {
 		if k.Collection == "user" && k.Field == "password" {
+			if reasons != nil {
+				reasons[k.String()] = "Nobody can see the password of a user"
+			}
 			continue
 		}
 		result[k.String()] = true
@@ -97,7 +300,180 @@ func superadminFields(result map[string]bool, collection string, fqfields []perm
 //
 // The return value is a set of fqfields. It can only contain fields, that where
 // requested.
+//
+// Each key is only requested once from the DataProvider for one call.
 func (ps Permission) RestrictFQFields(ctx context.Context, userID int, fqfields []string) (map[string]bool, error) {
+	allowed, err := ps.restrict(ctx, userID, fqfields, nil)
+	return allowed, classify(err)
+}
+
+// RestrictFQFieldsExplained is like RestrictFQFields but also returns the
+// reason for each requested fqfield, that the user can not see.
+//
+// It is slower then RestrictFQFields, since each object is checked on its own.
+func (ps *Permission) RestrictFQFieldsExplained(ctx context.Context, userID int, fqfields []string) (map[string]bool, map[string]string, error) {
+	reasons := make(map[string]string)
+	allowed, err := ps.restrict(ctx, userID, fqfields, reasons)
+	if err != nil {
+		return nil, nil, classify(err)
+	}
+	return allowed, reasons, nil
+}
+
+// RestrictFQIDs returns the fields of each object, that the user can see.
+//
+// The argument fqids is a list of objects like `motion/1`. The fields of each
+// object are taken from the models.yml. Template fields are expanded with the
+// replacements from the datastore.
+//
+// The returned map contains each requested fqid with the sorted list of its
+// visible fields. The list is empty, if the user can not see the object.
+func (ps *Permission) RestrictFQIDs(ctx context.Context, userID int, fqids []string) (map[string][]string, error) {
+	visible, err := ps.restrictFQIDs(ctx, userID, fqids)
+	return visible, classify(err)
+}
+
+func (ps *Permission) restrictFQIDs(ctx context.Context, userID int, fqids []string) (map[string][]string, error) {
+	ctx = dataprovider.WithCache(ctx)
+
+	fqfields, err := ps.expandFQIDs(ctx, fqids)
+	if err != nil {
+		return nil, fmt.Errorf("expanding fqids: %w", err)
+	}
+
+	allowed, err := ps.restrict(ctx, userID, fqfields, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make(map[string][]string, len(fqids))
+	for _, fqid := range fqids {
+		visible[fqid] = []string{}
+	}
+
+	for _, f := range fqfields {
+		if !allowed[f] {
+			continue
+		}
+
+		i := strings.LastIndex(f, "/")
+		visible[f[:i]] = append(visible[f[:i]], f[i+1:])
+	}
+
+	for _, fields := range visible {
+		sort.Strings(fields)
+	}
+	return visible, nil
+}
+
+// RestrictValues returns the values of all fields, that the user can see.
+//
+// The argument values contains fqfields with their values from the datastore.
+// Fields, that the user can not see, are not in the result. Fields with a value
+// filter are reduced to the part, that the user can see. For example
+// agenda_item/child_ids only contains the ids of visible agenda items.
+func (ps *Permission) RestrictValues(ctx context.Context, userID int, values map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	visible, err := ps.restrictValues(ctx, userID, values)
+	return visible, classify(err)
+}
+
+func (ps *Permission) restrictValues(ctx context.Context, userID int, values map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	ctx = dataprovider.WithCache(ctx)
+
+	fqfields := make([]string, 0, len(values))
+	for k := range values {
+		fqfields = append(fqfields, k)
+	}
+	sort.Strings(fqfields)
+
+	allowed, err := ps.restrict(ctx, userID, fqfields, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	superadmin, err := ps.dp.IsSuperadmin(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("checking for superadmin: %w", err)
+	}
+
+	visible := make(map[string]json.RawMessage, len(allowed))
+	for _, k := range fqfields {
+		if !allowed[k] {
+			continue
+		}
+
+		value := values[k]
+		fqfield, err := perm.ParseFQField(k)
+		if err != nil {
+			return nil, Error{typ: ErrInvalid, err: fmt.Errorf("decoding fqfield: %w", err)}
+		}
+
+		if f, ok := ps.hs.valueFilters[fqfield.Collection+"/"+fqfield.Field]; ok && !superadmin && value != nil {
+			value, err = f(ps.withDenialLog(ctx, userID, "collection", fqfield.Collection), userID, fqfield, value)
+			if err != nil {
+				return nil, fmt.Errorf("filtering value of %s: %w", k, err)
+			}
+		}
+		visible[k] = value
+	}
+	return visible, nil
+}
+
+// expandFQIDs returns all fqfields of the given fqids.
+func (ps *Permission) expandFQIDs(ctx context.Context, fqids []string) ([]string, error) {
+	var fqfields []string
+	var templateKeys []string
+	seen := make(map[string]bool, len(fqids))
+	for _, fqid := range fqids {
+		if seen[fqid] {
+			continue
+		}
+		seen[fqid] = true
+
+		parts := strings.Split(fqid, "/")
+		if len(parts) != 2 {
+			return nil, Error{typ: ErrInvalid, err: fmt.Errorf("invalid fqid %s", fqid)}
+		}
+
+		if id, err := strconv.Atoi(parts[1]); err != nil || id <= 0 {
+			return nil, Error{typ: ErrInvalid, err: fmt.Errorf("invalid id in fqid %s", fqid)}
+		}
+
+		fields, ok := models.Fields(parts[0])
+		if !ok {
+			return nil, Error{typ: ErrNotFound, err: fmt.Errorf("unknown collection: `%s`", parts[0])}
+		}
+
+		for _, field := range fields {
+			fqfields = append(fqfields, fqid+"/"+field)
+			if models.IsTemplate(field) {
+				templateKeys = append(templateKeys, fqid+"/"+field)
+			}
+		}
+	}
+
+	if err := ps.dp.Prefetch(ctx, templateKeys...); err != nil {
+		return nil, fmt.Errorf("prefetching template fields: %w", err)
+	}
+
+	for _, key := range templateKeys {
+		var replacements []string
+		if err := ps.dp.GetIfExist(ctx, key, &replacements); err != nil {
+			return nil, fmt.Errorf("getting replacements for %s: %w", key, err)
+		}
+
+		i := strings.LastIndex(key, "/")
+		for _, r := range replacements {
+			fqfields = append(fqfields, key[:i+1]+models.TemplateField(key[i+1:], r))
+		}
+	}
+	return fqfields, nil
+}
+
+// restrict implements RestrictFQFields. If reasons is not nil, it is filled
+// with the reasons for all fields, the user can not see.
+func (ps Permission) restrict(ctx context.Context, userID int, fqfields []string, reasons map[string]string) (map[string]bool, error) {
+	ctx = dataprovider.WithCache(ctx)
 	allowedFields := make(map[string]bool, len(fqfields))
 
 	superadmin, err := ps.dp.IsSuperadmin(ctx, userID)
@@ -110,32 +486,120 @@ func (ps Permission) RestrictFQFields(ctx context.Context, userID int, fqfields
 		return nil, fmt.Errorf("grouping fqfields: %w", err)
 	}
 
-	for name, fqfields := range grouped {
-		if superadmin {
-			if superadminFields(allowedFields, name, fqfields) {
-				continue
-			}
+	names := make([]string, 0, len(grouped))
+	for name := range grouped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Each collection is restricted concurrently with its own result maps.
+	// They are merged afterwards in the order of the collection names.
+	type groupResult struct {
+		allowed map[string]bool
+		reasons map[string]string
+		err     error
+	}
+	results := make([]groupResult, len(names))
+
+	err = parallel(ctx, ps.parallelism, len(names), func(i int) {
+		name := names[i]
+		result := groupResult{allowed: make(map[string]bool)}
+		if reasons != nil {
+			result.reasons = make(map[string]string)
 		}
+		start := time.Now()
+		result.err = ps.restrictCollection(ps.withDenialLog(ctx, userID, "collection", name), userID, superadmin, name, grouped[name], result.allowed, result.reasons)
+		ps.observeCollection(name, start, grouped[name], result.allowed)
+		results[i] = result
+	})
+	if err != nil {
+		return nil, fmt.Errorf("restricting collections: %w", err)
+	}
 
-		handler, ok := ps.hs.collections[name]
-		if !ok {
-			return nil, fmt.Errorf("unknown collection: `%s`", name)
+	for _, result := range results {
+		if result.err != nil {
+			return nil, result.err
 		}
 
-		if err := handler.RestrictFQFields(ctx, userID, fqfields, allowedFields); err != nil {
-			return nil, fmt.Errorf("restrict for collection %s: %w", name, err)
+		for k, v := range result.allowed {
+			allowedFields[k] = v
+		}
+		for k, v := range result.reasons {
+			reasons[k] = v
 		}
 	}
 	return allowedFields, nil
 }
 
+// restrictCollection restricts the fqfields of one collection.
+func (ps Permission) restrictCollection(ctx context.Context, userID int, superadmin bool, name string, fqfields []perm.FQField, allowed map[string]bool, reasons map[string]string) error {
+	if superadmin {
+		if superadminFields(allowed, reasons, name, fqfields) {
+			return nil
+		}
+	}
+
+	handler, ok := ps.hs.collections[name]
+	if !ok {
+		return Error{typ: ErrNotFound, err: fmt.Errorf("unknown collection: `%s`", name)}
+	}
+
+	if reasons != nil {
+		if err := restrictExplained(ctx, handler, userID, fqfields, allowed, reasons); err != nil {
+			return fmt.Errorf("restrict for collection %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if err := handler.RestrictFQFields(ctx, userID, fqfields, allowed); err != nil {
+		return fmt.Errorf("restrict for collection %s: %w", name, err)
+	}
+	return nil
+}
+
+// restrictExplained calls the handler for each object on its own, so the
+// reasons can be attached to the fields of the object.
+func restrictExplained(ctx context.Context, handler perm.Collection, userID int, fqfields []perm.FQField, result map[string]bool, reasons map[string]string) error {
+	byFQID := make(map[string][]perm.FQField)
+	var order []string
+	for _, fqfield := range fqfields {
+		fqid := fqfield.FQID()
+		if _, ok := byFQID[fqid]; !ok {
+			order = append(order, fqid)
+		}
+		byFQID[fqid] = append(byFQID[fqid], fqfield)
+	}
+
+	for _, fqid := range order {
+		objCtx, explanation := perm.WithExplanation(ctx)
+		if err := handler.RestrictFQFields(objCtx, userID, byFQID[fqid], result); err != nil {
+			return err
+		}
+
+		for _, fqfield := range byFQID[fqid] {
+			if !result[fqfield.String()] {
+				reasons[fqfield.String()] = reasonText(explanation.Reasons())
+			}
+		}
+	}
+	return nil
+}
+
+// reasonText joins a list of reasons.
+func reasonText(reasons []string) string {
+	if len(reasons) == 0 {
+		return "No reason given"
+	}
+	return strings.Join(reasons, "; ")
+}
+
 // groupFQFields sorts the fqfields and returns it grouped by collection.
 func groupFQFields(fqfields []string) (map[string][]perm.FQField, error) {
 	grouped := make(map[string][]perm.FQField)
 	for _, f := range fqfields {
 		fqfield, err := perm.ParseFQField(f)
 		if err != nil {
-			return nil, fmt.Errorf("decoding fqfield: %w", err)
+			return nil, Error{typ: ErrInvalid, err: fmt.Errorf("decoding fqfield: %w", err)}
 		}
 		grouped[fqfield.Collection] = append(grouped[fqfield.Collection], fqfield)
 	}
@@ -156,9 +620,135 @@ func (ps *Permission) AllRoutes() (collections []string, actions []string) {
 	return rr, wr
 }
 
-// AdditionalUpdate TODO
+// AdditionalUpdate returns the ids of all users, whose permissions could have
+// changed by the updated keys.
+//
+// The argument updated contains the changed keys with there new values. The
+// value of a deleted key is nil.
+//
+// The affected users are calculated from the datastore after the change. The
+// meeting of an object, that was deleted with this update, is taken from the
+// field <collection>_ids of the meeting in updated.
+//
+// For the returned users, all visible fields have to be recalculated. The id 0
+// stands for the anonymous user. The ids are sorted.
 func (ps *Permission) AdditionalUpdate(ctx context.Context, updated map[string]json.RawMessage) ([]int, error) {
-	return nil, nil
+	userIDs, err := ps.additionalUpdate(ctx, updated)
+	return userIDs, classify(err)
+}
+
+func (ps *Permission) additionalUpdate(ctx context.Context, updated map[string]json.RawMessage) ([]int, error) {
+	ctx = dataprovider.WithCache(ctx)
+
+	keys := make([]string, 0, len(updated))
+	for k := range updated {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	affected := make(map[int]bool)
+	for _, k := range keys {
+		fqfield, err := perm.ParseFQField(k)
+		if err != nil {
+			return nil, Error{typ: ErrInvalid, err: fmt.Errorf("decoding updated key: %w", err)}
+		}
+
+		for _, f := range ps.hs.dependencies[fqfield.Collection+"/"+fqfield.TemplatePrefix()] {
+			userIDs, err := f(ctx, fqfield, updated)
+			if err != nil {
+				return nil, fmt.Errorf("getting affected users for %s: %w", k, err)
+			}
+
+			for _, id := range userIDs {
+				affected[id] = true
+			}
+		}
+	}
+
+	userIDs := make([]int, 0, len(affected))
+	for id := range affected {
+		userIDs = append(userIDs, id)
+	}
+	sort.Ints(userIDs)
+	return userIDs, nil
+}
+
+// EffectivePermissions describes, what a user can do in a meeting.
+type EffectivePermissions struct {
+	UserID    int `json:"user_id"`
+	MeetingID int `json:"meeting_id"`
+
+	// Superadmin is true, if the user is allowed to do everything in the
+	// organisation. In this case, the other fields are still set.
+	Superadmin bool `json:"superadmin"`
+
+	// InMeeting is false, if the user is not a member of the meeting or, for
+	// the anonymous user, anonymous is not enabled in the meeting.
+	InMeeting bool `json:"in_meeting"`
+
+	Admin       bool     `json:"admin"`
+	Anonymous   bool     `json:"anonymous"`
+	GroupIDs    []int    `json:"group_ids"`
+	Permissions []string `json:"permissions"`
+}
+
+// EffectivePermissions returns the groups and permissions of a user in a
+// meeting.
+//
+// The permissions contain all derivated permissions. For example, a user with
+// motion.can_manage also has motion.can_see. A meeting admin has all
+// permissions. The userID 0 stands for the anonymous user.
+func (ps *Permission) EffectivePermissions(ctx context.Context, userID, meetingID int) (EffectivePermissions, error) {
+	eff, err := ps.effectivePermissions(ctx, userID, meetingID)
+	return eff, classify(err)
+}
+
+func (ps *Permission) effectivePermissions(ctx context.Context, userID, meetingID int) (EffectivePermissions, error) {
+	ctx = dataprovider.WithCache(ctx)
+	eff := EffectivePermissions{
+		UserID:      userID,
+		MeetingID:   meetingID,
+		GroupIDs:    []int{},
+		Permissions: []string{},
+	}
+
+	if meetingID <= 0 {
+		return eff, Error{typ: ErrInvalid, err: fmt.Errorf("invalid meeting id %d", meetingID)}
+	}
+
+	superadmin, err := ps.dp.IsSuperadmin(ctx, userID)
+	if err != nil {
+		return eff, fmt.Errorf("checking for superadmin: %w", err)
+	}
+	eff.Superadmin = superadmin
+
+	perms, err := perm.New(ctx, ps.dp, userID, meetingID)
+	if err != nil {
+		return eff, fmt.Errorf("getting permissions: %w", err)
+	}
+
+	if perms == nil {
+		return eff, nil
+	}
+
+	eff.InMeeting = true
+	eff.Admin = perms.IsAdmin()
+	eff.Anonymous = perms.IsAnonymous()
+	eff.GroupIDs = perms.GroupIDs()
+	if eff.Admin {
+		// perm.New does not return the groups of an admin.
+		var groupIDs []int
+		if err := ps.dp.GetIfExist(ctx, fmt.Sprintf("user/%d/group_$%d_ids", userID, meetingID), &groupIDs); err != nil {
+			return eff, fmt.Errorf("getting group ids: %w", err)
+		}
+		sort.Ints(groupIDs)
+		eff.GroupIDs = groupIDs
+	}
+
+	for _, p := range perms.Permissions() {
+		eff.Permissions = append(eff.Permissions, string(p))
+	}
+	return eff, nil
 }
 
 // DataProvider is the connection to the datastore. It returns the data
@@ -172,16 +762,20 @@ type DataProvider interface {
 	Get(ctx context.Context, fqfields ...string) ([]json.RawMessage, error)
 }
 
-// handlerStore saves the known actions and collections
+// handlerStore saves the known actions, collections and dependencies.
 type handlerStore struct {
-	actions     map[string]perm.Action
-	collections map[string]perm.Collection
+	actions      map[string]perm.Action
+	collections  map[string]perm.Collection
+	dependencies map[string][]perm.AffectedUsersFunc
+	valueFilters map[string]perm.ValueFilterFunc
 }
 
 func newHandlerStore() *handlerStore {
 	return &handlerStore{
-		actions:     make(map[string]perm.Action),
-		collections: make(map[string]perm.Collection),
+		actions:      make(map[string]perm.Action),
+		collections:  make(map[string]perm.Collection),
+		dependencies: make(map[string][]perm.AffectedUsersFunc),
+		valueFilters: make(map[string]perm.ValueFilterFunc),
 	}
 }
 
@@ -198,3 +792,21 @@ func (hs *handlerStore) RegisterAction(name string, action perm.Action) {
 	}
 	hs.actions[name] = action
 }
+
+func (hs *handlerStore) Restricter(name string) (perm.Collection, bool) {
+	c, ok := hs.collections[name]
+	return c, ok
+}
+
+func (hs *handlerStore) RegisterDependency(collection, field string, f perm.AffectedUsersFunc) {
+	key := collection + "/" + field
+	hs.dependencies[key] = append(hs.dependencies[key], f)
+}
+
+func (hs *handlerStore) RegisterValueFilter(collection, field string, f perm.ValueFilterFunc) {
+	key := collection + "/" + field
+	if _, ok := hs.valueFilters[key]; ok {
+		panic(fmt.Sprintf("Value filter for `%s` allready exists", key))
+	}
+	hs.valueFilters[key] = f
+}
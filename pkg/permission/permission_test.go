@@ -3,18 +3,23 @@ package permission
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
-// TODO Activate after developer-mode is removed.
-// func TestDispatchNotFound(t *testing.T) {
-// 	p := permission.New(nil, permission.WithCollections(fakeCollections()))
-// 	_, err := p.IsAllowed(context.Background(), "", 0, nil)
-// 	if err == nil {
-// 		t.Errorf("Got no error, expected one")
-// 	}
-// }
+func TestDispatchNotFound(t *testing.T) {
+	p := NewTestPermission()
+	_, err := p.IsAllowed(context.Background(), "", 0, nil)
+
+	var errPerm Error
+	if !errors.As(err, &errPerm) || errPerm.Type() != ErrNotFound {
+		t.Errorf("Got error `%v`, expected a not found error", err)
+	}
+}
 
 func TestDispatchAllowed(t *testing.T) {
 	p := NewTestPermission()
@@ -60,3 +65,499 @@ func TestErrorMessage(t *testing.T) {
 		t.Errorf("Error does not contain original error: %v", err)
 	}
 }
+
+type countingDataProvider struct {
+	mu    sync.Mutex
+	data  map[string]json.RawMessage
+	calls int
+}
+
+func (c *countingDataProvider) Get(ctx context.Context, keys ...string) ([]json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	values := make([]json.RawMessage, len(keys))
+	for i, key := range keys {
+		values[i] = c.data[key]
+	}
+	return values, nil
+}
+
+func TestRestrictFQFieldsPrefetch(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids":         []byte("[2]"),
+		"group/2/permissions":         []byte(`["motion.can_see"]`),
+		"motion_state/3/id":           []byte("3"),
+		"motion_state/3/restrictions": []byte(`[]`),
+	}}
+
+	var fqfields []string
+	for i := 1; i <= 100; i++ {
+		dp.data[fmt.Sprintf("motion/%d/meeting_id", i)] = []byte("1")
+		dp.data[fmt.Sprintf("motion/%d/state_id", i)] = []byte("3")
+		fqfields = append(fqfields, fmt.Sprintf("motion/%d/title", i))
+	}
+
+	p := New(dp)
+	got, err := p.RestrictFQFields(context.Background(), 1, fqfields)
+	if err != nil {
+		t.Fatalf("RestrictFQFields returned unexpected error: %v", err)
+	}
+
+	if len(got) != 100 {
+		t.Errorf("Got %d fields, expected 100", len(got))
+	}
+
+	if dp.calls > 10 {
+		t.Errorf("Datastore was called %d times, expected at most 10", dp.calls)
+	}
+}
+
+func TestRestrictAgendaItemsPrefetch(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids": []byte("[2]"),
+		"group/2/permissions": []byte(`["agenda_item.can_see"]`),
+	}}
+
+	// Each item 1-100 has its own parent and grandparent.
+	var fqfields []string
+	for i := 1; i <= 100; i++ {
+		for _, id := range []int{i, i + 100, i + 200} {
+			dp.data[fmt.Sprintf("agenda_item/%d/meeting_id", id)] = []byte("1")
+		}
+		dp.data[fmt.Sprintf("agenda_item/%d/parent_id", i)] = []byte(strconv.Itoa(i + 100))
+		dp.data[fmt.Sprintf("agenda_item/%d/parent_id", i+100)] = []byte(strconv.Itoa(i + 200))
+		fqfields = append(fqfields, fmt.Sprintf("agenda_item/%d/id", i))
+	}
+
+	p := New(dp)
+	got, err := p.RestrictFQFields(context.Background(), 1, fqfields)
+	if err != nil {
+		t.Fatalf("RestrictFQFields returned unexpected error: %v", err)
+	}
+
+	if len(got) != 100 {
+		t.Errorf("Got %d fields, expected 100", len(got))
+	}
+
+	if dp.calls > 10 {
+		t.Errorf("Datastore was called %d times, expected at most 10", dp.calls)
+	}
+}
+
+func TestIsAllowedWithReason(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids": []byte("[2]"),
+		"group/2/permissions": []byte(`["motion.can_see"]`),
+	}}
+	p := New(dp)
+
+	payload := []map[string]json.RawMessage{{"meeting_id": []byte("1"), "title": []byte(`"foo"`)}}
+	allowed, reason, err := p.IsAllowedWithReason(context.Background(), "motion.create", 1, payload)
+	if err != nil {
+		t.Fatalf("IsAllowedWithReason returned unexpected error: %v", err)
+	}
+
+	if allowed {
+		t.Errorf("Got allowed, expected not allowed")
+	}
+
+	if !strings.Contains(reason, "motion.can_create") {
+		t.Errorf("Reason `%s` does not contain the missing permission", reason)
+	}
+}
+
+func TestRestrictFQFieldsExplained(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids":         []byte("[2]"),
+		"group/2/permissions":         []byte(`["motion.can_see"]`),
+		"motion/1/meeting_id":         []byte("1"),
+		"motion/1/state_id":           []byte("3"),
+		"motion/2/meeting_id":         []byte("1"),
+		"motion/2/state_id":           []byte("4"),
+		"motion_state/4/restrictions": []byte(`["is_submitter"]`),
+	}}
+	p := New(dp)
+
+	allowed, reasons, err := p.RestrictFQFieldsExplained(context.Background(), 1, []string{"motion/1/title", "motion/2/title"})
+	if err != nil {
+		t.Fatalf("RestrictFQFieldsExplained returned unexpected error: %v", err)
+	}
+
+	if !allowed["motion/1/title"] || allowed["motion/2/title"] {
+		t.Errorf("Got allowed %v, expected only motion/1/title", allowed)
+	}
+
+	if _, ok := reasons["motion/1/title"]; ok {
+		t.Errorf("Got reason for allowed field motion/1/title")
+	}
+
+	if !strings.Contains(reasons["motion/2/title"], "is_submitter") {
+		t.Errorf("Reason `%s` does not contain the state restriction", reasons["motion/2/title"])
+	}
+}
+
+func TestAdditionalUpdate(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"group/5/user_ids":          []byte("[1, 2]"),
+		"meeting/1/user_ids":        []byte("[1, 2, 3]"),
+		"motion_state/9/meeting_id": []byte("1"),
+		"agenda_item/4/meeting_id":  []byte("1"),
+		"mediafile/6/meeting_id":    []byte("1"),
+	}}
+	p := New(dp)
+
+	for _, tt := range []struct {
+		name    string
+		updated map[string]json.RawMessage
+		expect  []int
+	}{
+		{
+			"group permissions",
+			map[string]json.RawMessage{"group/5/permissions": []byte(`["motion.can_see"]`)},
+			[]int{1, 2},
+		},
+		{
+			"user groups",
+			map[string]json.RawMessage{"user/7/group_$1_ids": []byte("[5]")},
+			[]int{0, 1, 2, 3, 7},
+		},
+		{
+			"user meetings",
+			map[string]json.RawMessage{"user/7/group_$_ids": []byte(`["1"]`)},
+			[]int{7},
+		},
+		{
+			"motion state restrictions",
+			map[string]json.RawMessage{"motion_state/9/restrictions": []byte(`["is_submitter"]`)},
+			[]int{0, 1, 2, 3},
+		},
+		{
+			"projected agenda item",
+			map[string]json.RawMessage{"agenda_item/4/is_internal": []byte("true")},
+			[]int{0, 1, 2, 3},
+		},
+		{
+			"projected mediafile",
+			map[string]json.RawMessage{"mediafile/6/current_projector_ids": []byte("[1]")},
+			[]int{0, 1, 2, 3},
+		},
+		{
+			"enable anonymous",
+			map[string]json.RawMessage{"meeting/1/enable_anonymous": []byte("true")},
+			[]int{0, 1, 2, 3},
+		},
+		{
+			"multiple keys",
+			map[string]json.RawMessage{
+				"group/5/permissions": []byte(`["motion.can_see"]`),
+				"user/7/group_$2_ids": []byte("[5]"),
+			},
+			[]int{0, 1, 2, 7},
+		},
+		{
+			"deleted projection",
+			map[string]json.RawMessage{
+				"projection/5/element_id":  nil,
+				"projection/5/meeting_id":  nil,
+				"meeting/1/projection_ids": []byte("[4]"),
+			},
+			[]int{0, 1, 2, 3},
+		},
+		{
+			"deleted projection without meeting",
+			map[string]json.RawMessage{"projection/5/element_id": nil},
+			[]int{},
+		},
+		{
+			"unrelated key",
+			map[string]json.RawMessage{"motion/1/title": []byte(`"foo"`)},
+			[]int{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.AdditionalUpdate(context.Background(), tt.updated)
+			if err != nil {
+				t.Fatalf("AdditionalUpdate returned unexpected error: %v", err)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.expect) {
+				t.Errorf("Got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestErrorTypes(t *testing.T) {
+	p := New(&countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/id": []byte("1"),
+	}})
+
+	for _, tt := range []struct {
+		name    string
+		call    func() error
+		errType string
+	}{
+		{
+			"unknown action",
+			func() error {
+				_, err := p.IsAllowed(context.Background(), "unknown.action", 1, []map[string]json.RawMessage{{}})
+				return err
+			},
+			ErrNotFound,
+		},
+		{
+			"unknown collection",
+			func() error {
+				_, err := p.RestrictFQFields(context.Background(), 1, []string{"unknown/1/field"})
+				return err
+			},
+			ErrNotFound,
+		},
+		{
+			"invalid fqfield",
+			func() error {
+				_, err := p.RestrictFQFields(context.Background(), 1, []string{"motion/1"})
+				return err
+			},
+			ErrInvalid,
+		},
+		{
+			"missing id",
+			func() error {
+				_, err := p.IsAllowed(context.Background(), "motion.delete", 1, []map[string]json.RawMessage{{}})
+				return err
+			},
+			ErrInvalid,
+		},
+		{
+			"datastore error",
+			func() error {
+				p := New(errorDataProvider{})
+				_, err := p.IsAllowed(context.Background(), "motion.delete", 1, []map[string]json.RawMessage{{"id": []byte("1")}})
+				return err
+			},
+			ErrDatastore,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil {
+				t.Fatalf("Got no error")
+			}
+
+			var permErr Error
+			if !errors.As(err, &permErr) {
+				t.Fatalf("Got error of type %T, expected Error", err)
+			}
+
+			if got := permErr.Type(); got != tt.errType {
+				t.Errorf("Got error type %s, expected %s: %v", got, tt.errType, err)
+			}
+		})
+	}
+}
+
+type errorDataProvider struct{}
+
+func (errorDataProvider) Get(ctx context.Context, fqfields ...string) ([]json.RawMessage, error) {
+	return nil, errors.New("datastore is down")
+}
+
+func TestEffectivePermissions(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"meeting/1/admin_group_id":   []byte("1"),
+		"meeting/1/default_group_id": []byte("2"),
+		"meeting/1/enable_anonymous": []byte("true"),
+		"group/2/permissions":        []byte(`["agenda_item.can_see"]`),
+		"group/3/permissions":        []byte(`["motion.can_manage"]`),
+		"user/1/group_$1_ids":        []byte("[1]"),
+		"user/2/group_$1_ids":        []byte("[3, 2]"),
+	}}
+	p := New(dp)
+
+	for _, tt := range []struct {
+		name   string
+		userID int
+		expect func(t *testing.T, eff EffectivePermissions)
+	}{
+		{
+			"admin",
+			1,
+			func(t *testing.T, eff EffectivePermissions) {
+				if !eff.Admin || !eff.InMeeting || eff.Anonymous {
+					t.Errorf("Got %+v, expected admin in meeting", eff)
+				}
+				if got := fmt.Sprint(eff.GroupIDs); got != "[1]" {
+					t.Errorf("Got groups %s, expected [1]", got)
+				}
+				if len(eff.Permissions) < 30 {
+					t.Errorf("Got %d permissions, expected all permissions", len(eff.Permissions))
+				}
+			},
+		},
+		{
+			"user",
+			2,
+			func(t *testing.T, eff EffectivePermissions) {
+				if eff.Admin || !eff.InMeeting || eff.Anonymous {
+					t.Errorf("Got %+v, expected normal user in meeting", eff)
+				}
+				if got := fmt.Sprint(eff.GroupIDs); got != "[2 3]" {
+					t.Errorf("Got groups %s, expected [2 3]", got)
+				}
+				got := strings.Join(eff.Permissions, ",")
+				expect := "agenda_item.can_see,motion.can_create,motion.can_create_amendments,motion.can_manage,motion.can_manage_metadata,motion.can_manage_polls,motion.can_see,motion.can_see_internal"
+				if got != expect {
+					t.Errorf("Got permissions %s, expected %s", got, expect)
+				}
+			},
+		},
+		{
+			"anonymous",
+			0,
+			func(t *testing.T, eff EffectivePermissions) {
+				if !eff.Anonymous || !eff.InMeeting {
+					t.Errorf("Got %+v, expected anonymous in meeting", eff)
+				}
+				if got := strings.Join(eff.Permissions, ","); got != "agenda_item.can_see" {
+					t.Errorf("Got permissions %s, expected agenda_item.can_see", got)
+				}
+			},
+		},
+		{
+			"not in meeting",
+			3,
+			func(t *testing.T, eff EffectivePermissions) {
+				if eff.InMeeting || len(eff.Permissions) != 0 {
+					t.Errorf("Got %+v, expected user not in meeting", eff)
+				}
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			eff, err := p.EffectivePermissions(context.Background(), tt.userID, 1)
+			if err != nil {
+				t.Fatalf("EffectivePermissions returned unexpected error: %v", err)
+			}
+			tt.expect(t, eff)
+		})
+	}
+}
+
+func TestRestrictFQIDs(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids":            []byte("[2]"),
+		"group/2/permissions":            []byte(`["motion.can_see"]`),
+		"motion_state/3/id":              []byte("3"),
+		"motion_state/3/restrictions":    []byte(`[]`),
+		"motion/1/meeting_id":            []byte("1"),
+		"motion/1/state_id":              []byte("3"),
+		"motion/1/amendment_paragraph_$": []byte(`["4"]`),
+		"motion/2/meeting_id":            []byte("2"),
+	}}
+
+	p := New(dp)
+	got, err := p.RestrictFQIDs(context.Background(), 1, []string{"motion/1", "motion/2"})
+	if err != nil {
+		t.Fatalf("RestrictFQIDs returned unexpected error: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("Got %d fqids, expected 2", len(got))
+	}
+
+	if fields := got["motion/2"]; len(fields) != 0 {
+		t.Errorf("Got visible fields %v for motion in other meeting, expected none", fields)
+	}
+
+	fields := strings.Join(got["motion/1"], ",")
+	for _, field := range []string{"title", "amendment_paragraph_$", "amendment_paragraph_$4"} {
+		if !strings.Contains(","+fields+",", ","+field+",") {
+			t.Errorf("Field %s is missing in %s", field, fields)
+		}
+	}
+
+	if _, err := p.RestrictFQIDs(context.Background(), 1, []string{"motion/1/title"}); err == nil {
+		t.Errorf("Got no error for invalid fqid")
+	}
+}
+
+func TestIsAllowedPerPayload(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids": []byte("[2]"),
+		"group/2/permissions": []byte(`["tag.can_manage"]`),
+		"tag/1/meeting_id":    []byte("1"),
+		"tag/2/meeting_id":    []byte("2"),
+	}}
+	p := New(dp)
+
+	results, err := p.IsAllowedPerPayload(context.Background(), "tag.update", 1, []map[string]json.RawMessage{
+		{"id": []byte("1")},
+		{"id": []byte("2")},
+		{"id": []byte(`"abc"`)},
+		{"id": []byte("1")},
+	})
+	if err != nil {
+		t.Fatalf("IsAllowedPerPayload returned unexpected error: %v", err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("Got %d results, expected 4", len(results))
+	}
+
+	if !results[0].Allowed || results[0].Err != nil {
+		t.Errorf("Payload 0: got %+v, expected allowed", results[0])
+	}
+
+	if results[1].Allowed || results[1].Reason == "" || results[1].Err != nil {
+		t.Errorf("Payload 1: got %+v, expected not allowed with reason", results[1])
+	}
+
+	var permErr Error
+	if results[2].Allowed || !errors.As(results[2].Err, &permErr) || permErr.Type() != ErrInvalid {
+		t.Errorf("Payload 2: got %+v, expected invalid error", results[2])
+	}
+
+	if !results[3].Allowed {
+		t.Errorf("Payload 3: got %+v, expected allowed", results[3])
+	}
+
+	if _, err := p.IsAllowedPerPayload(context.Background(), "unknown.action", 1, nil); err == nil {
+		t.Errorf("Got no error for unknown action")
+	}
+}
+
+func TestIsAllowedDeterministic(t *testing.T) {
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids": []byte("[2]"),
+		"group/2/permissions": []byte(`["tag.can_manage"]`),
+	}}
+
+	var payloads []map[string]json.RawMessage
+	for i := 1; i <= 50; i++ {
+		// Every 7th tag is in another meeting.
+		meetingID := "1"
+		if i%7 == 0 {
+			meetingID = "2"
+		}
+		dp.data[fmt.Sprintf("tag/%d/meeting_id", i)] = []byte(meetingID)
+		payloads = append(payloads, map[string]json.RawMessage{"id": []byte(strconv.Itoa(i))})
+	}
+
+	p := New(dp, WithParallelism(8))
+	for i := 0; i < 20; i++ {
+		allowed, reason, err := p.IsAllowedWithReason(context.Background(), "tag.update", 1, payloads)
+		if err != nil {
+			t.Fatalf("IsAllowedWithReason returned unexpected error: %v", err)
+		}
+
+		if allowed {
+			t.Fatalf("Got allowed, expected not allowed")
+		}
+
+		if !strings.HasPrefix(reason, "payload-index 6:") {
+			t.Fatalf("Got reason `%s`, expected the reason for payload 6", reason)
+		}
+	}
+}
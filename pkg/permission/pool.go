@@ -0,0 +1,81 @@
+package permission
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultParallelism is the number of payloads or collections, that are
+// checked at the same time, if WithParallelism is not used.
+const defaultParallelism = 4
+
+// parallel calls f for each index from 0 to n-1 with at most limit calls at the
+// same time.
+//
+// When the context is canceled, no new calls are started and the error of the
+// context is returned after all running calls are finished.
+func parallel(ctx context.Context, limit, n int, f func(i int)) error {
+	if limit > n {
+		limit = n
+	}
+
+	if limit <= 1 {
+		for i := 0; i < n; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			f(i)
+		}
+		return nil
+	}
+
+	next := int64(-1)
+	var wg sync.WaitGroup
+	wg.Add(limit)
+	for w := 0; w < limit; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n || ctx.Err() != nil {
+					return
+				}
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// minIndex saves the smallest index, that was given to it. It can be used from
+// different goroutines.
+type minIndex struct {
+	value int64
+}
+
+func newMinIndex() *minIndex {
+	return &minIndex{value: -1}
+}
+
+// set saves i, if it is smaller then the current value.
+func (m *minIndex) set(i int) {
+	for {
+		old := atomic.LoadInt64(&m.value)
+		if old != -1 && old <= int64(i) {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&m.value, old, int64(i)) {
+			return
+		}
+	}
+}
+
+// before returns true, if i is smaller then the saved value or if no value was
+// saved.
+func (m *minIndex) before(i int) bool {
+	v := atomic.LoadInt64(&m.value)
+	return v == -1 || int64(i) < v
+}
@@ -0,0 +1,81 @@
+package permission
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestParallel(t *testing.T) {
+	for _, limit := range []int{1, 3, 100} {
+		var mu sync.Mutex
+		var running, maxRunning int
+		called := make([]bool, 20)
+
+		err := parallel(context.Background(), limit, len(called), func(i int) {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			called[i] = true
+			mu.Unlock()
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("parallel returned unexpected error: %v", err)
+		}
+
+		if maxRunning > limit {
+			t.Errorf("Limit %d: got %d calls at the same time", limit, maxRunning)
+		}
+
+		for i, c := range called {
+			if !c {
+				t.Errorf("Limit %d: index %d was not called", limit, i)
+			}
+		}
+	}
+}
+
+func TestParallelCanceled(t *testing.T) {
+	for _, limit := range []int{1, 4} {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var mu sync.Mutex
+		var calls int
+		err := parallel(ctx, limit, 1000, func(i int) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			cancel()
+		})
+
+		if err != context.Canceled {
+			t.Errorf("Limit %d: got error %v, expected context.Canceled", limit, err)
+		}
+
+		if calls > limit {
+			t.Errorf("Limit %d: got %d calls after cancel", limit, calls)
+		}
+	}
+}
+
+func TestMinIndex(t *testing.T) {
+	m := newMinIndex()
+	if !m.before(100) {
+		t.Errorf("Empty minIndex: before(100) returned false")
+	}
+
+	m.set(5)
+	m.set(10)
+	m.set(3)
+	m.set(4)
+
+	if !m.before(2) || m.before(3) || m.before(4) {
+		t.Errorf("Got wrong values from minIndex with value %d", m.value)
+	}
+}
@@ -0,0 +1,218 @@
+package permission
+
+import (
+	"bytes"
+	_ "embed" // Needed for the default rules.
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/OpenSlides/openslides-permission-service/internal/collection"
+	"github.com/OpenSlides/openslides-permission-service/internal/dataprovider"
+	"github.com/OpenSlides/openslides-permission-service/internal/models"
+	"github.com/OpenSlides/openslides-permission-service/internal/perm"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules.yml
+var defaultRules []byte
+
+// rulesVersion is the version of the rules file, that is supported.
+const rulesVersion = 1
+
+// Rules defines actions and collections, that only need simple checks.
+//
+// Use LoadRules to create a Rules object.
+type Rules struct {
+	actions     map[string]collection.WriteRule
+	orgaManager []string
+	collections map[string]string
+}
+
+// DefaultRules returns the rules, that are used, if no other rules are given.
+func DefaultRules() *Rules {
+	rules, err := LoadRules(bytes.NewReader(defaultRules))
+	if err != nil {
+		panic(fmt.Sprintf("Invalid default rules: %v", err))
+	}
+	return rules
+}
+
+// LoadRules reads rules from a yaml or json document.
+//
+// The rules are validated. It is an error, if a permission is unknown or if a
+// rule is defined for an action or collection that is already implemented by
+// the permission service.
+func LoadRules(r io.Reader) (*Rules, error) {
+	var content struct {
+		Version     int                   `yaml:"version"`
+		Actions     map[string]actionRule `yaml:"actions"`
+		OrgaManager []string              `yaml:"orga_manager"`
+		Collections map[string]string     `yaml:"collections"`
+	}
+
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&content); err != nil {
+		return nil, fmt.Errorf("decoding rules: %w", err)
+	}
+
+	if content.Version != rulesVersion {
+		return nil, fmt.Errorf("unsupported rules version %d, expected %d", content.Version, rulesVersion)
+	}
+
+	rules := &Rules{
+		actions:     make(map[string]collection.WriteRule, len(content.Actions)),
+		orgaManager: content.OrgaManager,
+		collections: content.Collections,
+	}
+	for name, rule := range content.Actions {
+		rules.actions[name] = collection.WriteRule(rule)
+	}
+
+	if err := rules.validate(); err != nil {
+		return nil, fmt.Errorf("validating rules: %w", err)
+	}
+	return rules, nil
+}
+
+// validate checks, that the rules can be registered.
+func (r *Rules) validate() error {
+	builtin := newHandlerStore()
+	for _, con := range openSlidesCollections(dataprovider.DataProvider{}) {
+		con.Connect(builtin)
+	}
+
+	actions := make(map[string]bool)
+	checkAction := func(name string) error {
+		parts := strings.Split(name, ".")
+		if len(parts) != 2 || parts[1] == "" {
+			return fmt.Errorf("invalid action name `%s`", name)
+		}
+
+		if _, ok := models.Fields(parts[0]); !ok {
+			return fmt.Errorf("action `%s`: unknown collection `%s`", name, parts[0])
+		}
+
+		if _, ok := builtin.actions[name]; ok || actions[name] {
+			return fmt.Errorf("action `%s` is defined more then once", name)
+		}
+		actions[name] = true
+		return nil
+	}
+
+	for _, name := range r.actionNames() {
+		if err := checkAction(name); err != nil {
+			return err
+		}
+
+		rule := r.actions[name]
+		if !rule.Permission.Valid() {
+			return fmt.Errorf("action `%s`: unknown permission `%s`", name, rule.Permission)
+		}
+
+		if rule.Collection != "" {
+			if _, ok := models.Fields(rule.Collection); !ok {
+				return fmt.Errorf("action `%s`: unknown collection `%s`", name, rule.Collection)
+			}
+		}
+	}
+
+	for _, name := range r.orgaManager {
+		if err := checkAction(name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range r.collectionNames() {
+		if _, ok := models.Fields(name); !ok {
+			return fmt.Errorf("unknown collection `%s`", name)
+		}
+
+		if _, ok := builtin.collections[name]; ok {
+			return fmt.Errorf("collection `%s` is already implemented", name)
+		}
+
+		switch rule := r.collections[name]; rule {
+		case "public", "in_meeting":
+		default:
+			if !perm.TPermission(rule).Valid() {
+				return fmt.Errorf("collection `%s`: unknown read rule `%s`", name, rule)
+			}
+		}
+	}
+	return nil
+}
+
+// connecters returns the Connecters for the rules.
+func (r *Rules) connecters(dp dataprovider.DataProvider) []perm.Connecter {
+	var public, inMeeting []string
+	readPerms := make(map[perm.TPermission][]string)
+	for _, name := range r.collectionNames() {
+		switch rule := r.collections[name]; rule {
+		case "public":
+			public = append(public, name)
+		case "in_meeting":
+			inMeeting = append(inMeeting, name)
+		default:
+			readPerms[perm.TPermission(rule)] = append(readPerms[perm.TPermission(rule)], name)
+		}
+	}
+
+	cons := []perm.Connecter{
+		collection.Public(dp, public...),
+		collection.ReadInMeeting(dp, inMeeting...),
+		collection.OrgaManager(dp, r.orgaManager...),
+		collection.WritePerm(dp, r.actions),
+	}
+	for p, collections := range readPerms {
+		cons = append(cons, collection.ReadPerm(dp, p, collections...))
+	}
+	return cons
+}
+
+// actionRule is the definition of an action in the rules file.
+//
+// It can be written as string with the permission or as object with the
+// fields permission and collection.
+type actionRule struct {
+	Permission perm.TPermission `yaml:"permission"`
+	Collection string           `yaml:"collection"`
+}
+
+// UnmarshalYAML decodes the short and the long form of an action rule.
+func (a *actionRule) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&a.Permission)
+	}
+
+	type plain actionRule
+	var rule plain
+	if err := value.Decode(&rule); err != nil {
+		return err
+	}
+	*a = actionRule(rule)
+	return nil
+}
+
+// actionNames returns the names of all actions with a permission in sorted
+// order.
+func (r *Rules) actionNames() []string {
+	names := make([]string, 0, len(r.actions))
+	for k := range r.actions {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectionNames returns the names of all collections in sorted order.
+func (r *Rules) collectionNames() []string {
+	names := make([]string, 0, len(r.collections))
+	for k := range r.collections {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,124 @@
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRules(t *testing.T) {
+	rules := DefaultRules()
+
+	if len(rules.actions) == 0 || len(rules.collections) == 0 || len(rules.orgaManager) == 0 {
+		t.Errorf("Default rules are incomplete: %d actions, %d collections, %d orga manager actions", len(rules.actions), len(rules.collections), len(rules.orgaManager))
+	}
+}
+
+func TestLoadRulesInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		rules  string
+		errMsg string
+	}{
+		{
+			"wrong version",
+			"version: 2",
+			"unsupported rules version 2",
+		},
+		{
+			"unknown field",
+			"version: 1\nroutes: {}",
+			"field routes not found",
+		},
+		{
+			"unknown permission",
+			"version: 1\nactions:\n  tag.create: tag.can_dance",
+			"unknown permission `tag.can_dance`",
+		},
+		{
+			"invalid action name",
+			"version: 1\nactions:\n  create: tag.can_manage",
+			"invalid action name `create`",
+		},
+		{
+			"builtin action",
+			"version: 1\nactions:\n  motion.delete: motion.can_manage",
+			"action `motion.delete` is defined more then once",
+		},
+		{
+			"action and orga manager",
+			"version: 1\nactions:\n  tag.create: tag.can_manage\norga_manager:\n  - tag.create",
+			"action `tag.create` is defined more then once",
+		},
+		{
+			"unknown collection",
+			"version: 1\ncollections:\n  dance: public",
+			"unknown collection `dance`",
+		},
+		{
+			"builtin collection",
+			"version: 1\ncollections:\n  motion: public",
+			"collection `motion` is already implemented",
+		},
+		{
+			"unknown read rule",
+			"version: 1\ncollections:\n  tag: everybody",
+			"unknown read rule `everybody`",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadRules(strings.NewReader(tt.rules))
+			if err == nil {
+				t.Fatalf("Got no error")
+			}
+
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Got error `%v`, expected it to contain `%s`", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestWithRules(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader(`
+version: 1
+actions:
+  tag.create: tag.can_manage
+  tag.update:
+    permission: tag.can_manage
+    collection: motion
+collections:
+  tag: public
+`))
+	if err != nil {
+		t.Fatalf("LoadRules returned unexpected error: %v", err)
+	}
+
+	dp := &countingDataProvider{data: map[string]json.RawMessage{
+		"user/1/group_$1_ids": []byte("[2]"),
+		"group/2/permissions": []byte(`["tag.can_manage"]`),
+		"motion/5/meeting_id": []byte("1"),
+	}}
+	p := New(dp, WithRules(rules))
+
+	allowed, err := p.IsAllowed(context.Background(), "tag.update", 1, []map[string]json.RawMessage{{"id": []byte("5")}})
+	if err != nil {
+		t.Fatalf("IsAllowed returned unexpected error: %v", err)
+	}
+	if !allowed {
+		t.Errorf("tag.update is not allowed")
+	}
+
+	if _, err := p.IsAllowed(context.Background(), "topic.create", 1, []map[string]json.RawMessage{{"meeting_id": []byte("1")}}); err == nil {
+		t.Errorf("Got no error for action that is not in the rules")
+	}
+
+	got, err := p.RestrictFQFields(context.Background(), 1, []string{"tag/1/name"})
+	if err != nil {
+		t.Fatalf("RestrictFQFields returned unexpected error: %v", err)
+	}
+	if !got["tag/1/name"] {
+		t.Errorf("Public tag is not visible")
+	}
+}